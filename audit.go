@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// auditor checks the integrity of the records stored by db, optionally
+// comparing them with the records written during a run.
+type auditor struct {
+	db    dbProvider
+	empty record.Record
+
+	// ledger holds the records inserted or updated during the run, and before
+	// is the number of records stored before it. If ledger is nil only the
+	// stored records are checked.
+	ledger *verifier
+	before uint64
+}
+
+// audit scans every stored record, returning the number of records, and the
+// number of invalid records (undecodable, or with unknown or missing fields),
+// duplicate IDs and gaps in the IDs (from 1 to the highest ID) as name/value
+// pairs. An error is returned if the records are not scanned in ID order.
+//
+// If a ledger is set the number of records is compared with the number
+// expected from the successful inserts, and the checksum of each record in the
+// ledger is compared with the stored record - records that were not updated
+// since their checksum was recorded must match (otherwise they are corrupt),
+// and records inserted during the run but missing from the table/collection
+// are lost.
+func (a *auditor) audit() ([][]string, error) {
+	var (
+		count, invalid, duplicates, gaps uint64
+		found, corrupt                   uint64
+		prev                             uint64
+		unordered                        bool
+	)
+
+	err := a.db.ScanRecords(a.empty, func(rec record.Record, err error) {
+		count++
+		if err != nil || rec.GetID() == 0 {
+			invalid++
+			return
+		}
+
+		id := rec.GetID()
+		switch {
+		case id < prev:
+			unordered = true
+			return
+		case id == prev:
+			duplicates++
+			return
+		case id > prev+1:
+			gaps += id - prev - 1
+		}
+		prev = id
+
+		if a.ledger == nil {
+			return
+		}
+
+		entry, ok := a.ledger.lookup(id)
+		if !ok {
+			return
+		}
+		if entry.inserted {
+			found++
+		}
+		if entry.known && !entry.updated && record.Checksum(rec) != entry.checksum {
+			corrupt++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unordered {
+		return nil, errors.New("records not scanned in ID order")
+	}
+
+	rows := [][]string{
+		{"AuditRecords:", strconv.FormatUint(count, 10)},
+		{"AuditInvalid:", strconv.FormatUint(invalid, 10)},
+		{"AuditDuplicates:", strconv.FormatUint(duplicates, 10)},
+		{"AuditGaps:", strconv.FormatUint(gaps, 10)},
+	}
+	if a.ledger == nil {
+		return rows, nil
+	}
+
+	inserted := a.ledger.written()
+	expected := a.before + inserted
+	if count != expected {
+		log.Printf("audit: found %d records, expected %d", count, expected)
+	}
+
+	return append(rows,
+		[]string{"AuditExpectedRecords:", strconv.FormatUint(expected, 10)},
+		[]string{"AuditCountDifference:", strconv.FormatInt(int64(count)-int64(expected), 10)},
+		[]string{"AuditLost:", strconv.FormatUint(inserted-found, 10)},
+		[]string{"AuditCorrupt:", strconv.FormatUint(corrupt, 10)},
+	), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ScanRecords calls f for each record in ID order.
+func (f *fakeDB) ScanRecords(into record.Record, fn func(record.Record, error)) error {
+	ids := make([]uint64, 0, len(f.records))
+	for id := range f.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		rec := into.Empty()
+		*rec.(*record.Person) = f.records[id]
+		fn(rec, nil)
+	}
+	return nil
+}
+
+// scanDB returns a record with each of ids from ScanRecords, in the order
+// given, with 0 returning a decoding error.
+type scanDB struct {
+	dbProvider
+	ids []uint64
+}
+
+func (s *scanDB) ScanRecords(into record.Record, f func(record.Record, error)) error {
+	for _, id := range s.ids {
+		rec := into.Empty()
+		if id == 0 {
+			f(rec, errors.New("invalid record"))
+			continue
+		}
+		rec.SetID(id)
+		f(rec, nil)
+	}
+	return nil
+}
+
+// auditRows returns the rows of a.audit as a map.
+func auditRows(t *testing.T, a *auditor) map[string]string {
+	rows, err := a.audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := map[string]string{}
+	for _, r := range rows {
+		out[r[0]] = r[1]
+	}
+	return out
+}
+
+func TestAuditor_Scan(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []uint64
+		want map[string]string
+	}{
+		{
+			name: "contiguous",
+			ids:  []uint64{1, 2, 3},
+			want: map[string]string{"AuditRecords:": "3", "AuditInvalid:": "0", "AuditDuplicates:": "0", "AuditGaps:": "0"},
+		},
+		{
+			name: "gaps",
+			ids:  []uint64{2, 3, 6},
+			want: map[string]string{"AuditRecords:": "3", "AuditDuplicates:": "0", "AuditGaps:": "3"},
+		},
+		{
+			name: "duplicates",
+			ids:  []uint64{1, 1, 2, 2, 2},
+			want: map[string]string{"AuditRecords:": "5", "AuditDuplicates:": "3", "AuditGaps:": "0"},
+		},
+		{
+			name: "invalid",
+			ids:  []uint64{1, 0, 2},
+			want: map[string]string{"AuditRecords:": "3", "AuditInvalid:": "1", "AuditGaps:": "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &auditor{db: &scanDB{ids: tt.ids}, empty: record.NewPerson(nil, nil, nil)}
+
+			got := auditRows(t, a)
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("%s got %s, want %s", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditor_ScanNotInOrder(t *testing.T) {
+	a := &auditor{db: &scanDB{ids: []uint64{1, 3, 2}}, empty: record.NewPerson(nil, nil, nil)}
+	if _, err := a.audit(); err == nil {
+		t.Error("expected an error for records not scanned in ID order")
+	}
+}
+
+func TestAuditor_Ledger(t *testing.T) {
+	db := newFakeDB()
+	rnd := rand.New(rand.NewSource(42))
+
+	existing := record.NewPerson(nil, nil, nil)
+	existing.Randomise(rnd)
+	existing.SetID(1)
+	db.put(existing)
+
+	v := newVerifier(db, false)
+	a := &auditor{db: db, empty: record.NewPerson(nil, nil, nil), ledger: v, before: 1}
+
+	// Update the existing record, adding it to the ledger, then insert records
+	// 2 and 3
+	data := record.NewPerson(nil, nil, nil)
+	id := &fixedGenerator{id: 1}
+	v.UpdateRecord(data, id, rnd)
+	v.check(data)
+	for i := 0; i < 2; i++ {
+		v.InsertRecord(data, id, rnd)
+		v.check(data)
+	}
+
+	// Lose the updated record and one inserted record, and corrupt the other
+	delete(db.records, 1)
+	delete(db.records, 3)
+	p := db.records[2]
+	p.Name = "corrupt"
+	db.records[2] = p
+
+	got := auditRows(t, a)
+	want := map[string]string{
+		"AuditRecords:":         "1",
+		"AuditExpectedRecords:": "3",
+		"AuditCountDifference:": "-2",
+		"AuditLost:":            "1",
+		"AuditCorrupt:":         "1",
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s got %s, want %s", k, got[k], w)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// compareSeed seeds the records loaded by compareAggregates, so both providers
+// store the same dataset.
+const compareSeed = 42
+
+// compareAggregates loads n identical records into the empty tables/collections
+// of a and b, then runs each aggregation against both and checks the results
+// match.
+//
+// The outcome of each comparison is returned as name/value pairs, and
+// mismatched is true if any result differs.
+func compareAggregates(a, b dbProvider, records record.Source, n, topN uint64) (rows [][]string, mismatched bool, err error) {
+	for _, db := range []dbProvider{a, b} {
+		if err := loadSeeded(db, records, n); err != nil {
+			return nil, false, err
+		}
+	}
+
+	checks := []struct {
+		name    string
+		compare func() error
+	}{
+		{"EnabledCounts:", func() error {
+			ra, err := a.EnabledCounts()
+			if err != nil {
+				return err
+			}
+			rb, err := b.EnabledCounts()
+			if err != nil {
+				return err
+			}
+			return query.CompareEnabledCounts(ra, rb)
+		}},
+		{"AgeBuckets:", func() error {
+			ra, err := a.AgeBuckets()
+			if err != nil {
+				return err
+			}
+			rb, err := b.AgeBuckets()
+			if err != nil {
+				return err
+			}
+			return query.CompareAgeBuckets(ra, rb)
+		}},
+		{"TopCounters:", func() error {
+			ra, err := a.CounterRanks(topN)
+			if err != nil {
+				return err
+			}
+			rb, err := b.CounterRanks(topN)
+			if err != nil {
+				return err
+			}
+			return query.CompareTopCounters(ra, rb)
+		}},
+	}
+
+	rows = [][]string{{"Records:", fmt.Sprint(n)}}
+	for _, c := range checks {
+		outcome := "match"
+		if err := c.compare(); err != nil {
+			outcome = err.Error()
+			mismatched = true
+		}
+		rows = append(rows, []string{c.name, outcome})
+	}
+
+	return rows, mismatched, nil
+}
+
+// loadSeeded inserts n records with IDs 1 to n into the empty table/collection
+// of db, generated from compareSeed.
+func loadSeeded(db dbProvider, records record.Source, n uint64) error {
+	count, err := db.CountRecords()
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return fmt.Errorf("table/collection contains %d records, run teardown and setup first", count)
+	}
+
+	rnd := rand.New(rand.NewSource(compareSeed))
+	id := (&idgen.MonotonicSource{}).New()
+	rec := records.New()
+	for i := uint64(0); i < n; i++ {
+		if _, ok := db.InsertRecord(rec, id, rnd); !ok {
+			return fmt.Errorf("failed to insert record %d", i+1)
+		}
+	}
+
+	return nil
+}
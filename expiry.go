@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+)
+
+// expirer runs the background tasks of the expire workload - purging expired
+// records (for databases without a TTL index) and sampling the size of the
+// expiring table/collection.
+type expirer struct {
+	db    dbProvider
+	start time.Time
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	// Only accessed by the purge goroutine until stopped. serverExpiry is set
+	// if the database removes expired records itself.
+	serverExpiry bool
+	purges       uint64
+	purged       uint64
+	purgeTime    time.Duration
+	purgeSlow    time.Duration
+	purgeFails   uint64
+
+	// Only accessed by the sampling goroutine until stopped.
+	samples [][]string
+}
+
+// startExpirer starts purging expired records every purgeInterval, and
+// sampling the size of the expiring table/collection every sizeInterval.
+func startExpirer(db dbProvider, purgeInterval, sizeInterval time.Duration) *expirer {
+	e := &expirer{
+		db:    db,
+		start: time.Now(),
+		stop:  make(chan struct{}),
+	}
+
+	e.wg.Add(2)
+	go e.every(purgeInterval, e.purge)
+	go e.every(sizeInterval, e.sample)
+
+	return e
+}
+
+// every calls f every interval until e is stopped, or f returns false.
+func (e *expirer) every(interval time.Duration, f func() bool) {
+	defer e.wg.Done()
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			if !f() {
+				return
+			}
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// purge deletes the expired records, recording the time taken. It returns false
+// if the database removes expired records itself.
+func (e *expirer) purge() bool {
+	start := time.Now()
+	n, err := e.db.PurgeExpired()
+	took := time.Since(start)
+	if err == query.ErrServerExpiry {
+		e.serverExpiry = true
+		return false
+	}
+	if err != nil {
+		log.Printf("purge: %v", err)
+		e.purgeFails++
+		return true
+	}
+
+	e.purges++
+	e.purged += n
+	e.purgeTime += took
+	if took > e.purgeSlow {
+		e.purgeSlow = took
+	}
+	return true
+}
+
+// sample records the size of the expiring table/collection.
+func (e *expirer) sample() bool {
+	rows, size, err := e.db.ExpiringSize()
+	if err != nil {
+		log.Printf("expiring size: %v", err)
+		return true
+	}
+
+	at := time.Since(e.start).Truncate(time.Second)
+	e.samples = append(e.samples,
+		[]string{fmt.Sprintf("ExpiringRows@%v:", at), strconv.FormatUint(rows, 10)},
+		[]string{fmt.Sprintf("ExpiringBytes@%v:", at), strconv.FormatUint(size, 10)},
+	)
+	return true
+}
+
+// verify stops the background tasks, returning the purge statistics (unless the
+// database removes expired records itself) and size samples as name/value
+// pairs.
+func (e *expirer) verify() ([][]string, []plan.Result, error) {
+	close(e.stop)
+	e.wg.Wait()
+
+	if e.serverExpiry {
+		return e.samples, nil, nil
+	}
+
+	var avg time.Duration
+	if e.purges > 0 {
+		avg = e.purgeTime / time.Duration(e.purges)
+	}
+
+	rows := [][]string{
+		{"Purges:", strconv.FormatUint(e.purges, 10)},
+		{"PurgeFailures:", strconv.FormatUint(e.purgeFails, 10)},
+		{"PurgedRows:", strconv.FormatUint(e.purged, 10)},
+		{"PurgeAvgLatency:", avg.String()},
+		{"PurgeMaxLatency:", e.purgeSlow.String()},
+	}
+
+	return append(rows, e.samples...), nil, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lockSampleInterval is how often the number of operations waiting on a lock
+// is sampled.
+const lockSampleInterval = 100 * time.Millisecond
+
+// lockSampler periodically samples the number of operations waiting to acquire
+// a lock during a run, as neither database keeps a cumulative count of lock
+// waits.
+type lockSampler struct {
+	db   dbProvider
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	// Only accessed by the sampling goroutine until stopped.
+	samples uint64
+	waiting uint64
+	total   uint64
+	max     uint64
+	fails   uint64
+}
+
+// newLockSampler returns a lockSampler for db, sampling once started.
+func newLockSampler(db dbProvider) *lockSampler {
+	return &lockSampler{
+		db:   db,
+		stop: make(chan struct{}),
+	}
+}
+
+// start starts sampling the lock waits every lockSampleInterval.
+func (l *lockSampler) start() {
+	l.wg.Add(1)
+	go l.run()
+}
+
+func (l *lockSampler) run() {
+	defer l.wg.Done()
+
+	tick := time.NewTicker(lockSampleInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			l.sample()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// sample records the number of operations currently waiting on a lock.
+func (l *lockSampler) sample() {
+	n, err := l.db.LockWaits()
+	if err != nil {
+		log.Printf("lock waits: %v", err)
+		l.fails++
+		return
+	}
+
+	l.samples++
+	l.total += n
+	if n > 0 {
+		l.waiting++
+	}
+	if n > l.max {
+		l.max = n
+	}
+}
+
+// rows stops the sampler, returning the number of samples, the number of
+// samples with at least one waiting operation, and the average and maximum
+// number of waiting operations as name/value pairs.
+func (l *lockSampler) rows() [][]string {
+	close(l.stop)
+	l.wg.Wait()
+
+	var avg float64
+	if l.samples > 0 {
+		avg = float64(l.total) / float64(l.samples)
+	}
+
+	return [][]string{
+		{"LockWaitSamples:", strconv.FormatUint(l.samples, 10)},
+		{"LockWaitSampleFailures:", strconv.FormatUint(l.fails, 10)},
+		{"LockWaitSamplesWaiting:", strconv.FormatUint(l.waiting, 10)},
+		{"LockWaitAvg:", strconv.FormatFloat(avg, 'f', 2, 64)},
+		{"LockWaitMax:", strconv.FormatUint(l.max, 10)},
+	}
+}
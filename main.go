@@ -14,39 +14,119 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/c2h5oh/datasize"
 	"github.com/domodwyer/mpjbt/mongo"
 	"github.com/domodwyer/mpjbt/plan"
 	"github.com/domodwyer/mpjbt/postgres"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/domodwyer/mpjbt/schema"
 )
 
 var (
 	endpoint, tableName, histPath, paddingSize string
+	schemaPath, paddingType                    string
+	compressibility                            float64
 	updateFreq, readFreq, numWorkers           uint64
 	opsMax                                     uint64
 	timeout                                    time.Duration
 
-	workload string
+	workload, command string
+	workloadOpts      workloadOptions
+
+	indexes, ginOpClass, compression string
+	fillFactor                       int
+	partitionInterval, eventTTL      time.Duration
+
+	distribution, ageRange string
+	nameCardinality        int
+	skew                   float64
+
+	compareEndpoint string
+	compareRecords  uint64
 
 	versionTag  = "unknown"
 	versionDate = "unknown"
 )
 
-func init() {
+// parseFlags parses the command line flags, printing the usage and exiting if
+// they are invalid.
+func parseFlags() {
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 	fs.StringVar(&endpoint, "connect", "", "Connection string")
 	fs.StringVar(&histPath, "histogram", "", "Histogram output file path (CSV)")
 	fs.StringVar(&tableName, "table", "test", "Table/collection name")
-	fs.StringVar(&paddingSize, "padding", "0", "Amount of binary padding in the records (valid suffixes: kb, mb)")
+
+	fs.StringVar(&paddingSize, "padding", "0", "Amount of padding in the records: a fixed `size` (valid suffixes: kb, mb), uniform:min:max, normal:mean:stddev, lognormal:median:sigma or histogram:file")
+	fs.StringVar(&paddingType, "padding-type", "random", "Record padding content (random, repeated, lorem, base64)")
+	fs.Float64Var(&compressibility, "compressibility", 1.0, "Target compressed size of the padding as a `fraction` of its size (1 == incompressible)")
+	fs.StringVar(&schemaPath, "schema", "", "Generate records from the JSON Schema-like `file` instead of the built-in person record")
+	fs.StringVar(&distribution, "distribution", "random", "Record field value distribution (random, realistic)")
+	fs.StringVar(&ageRange, "age-range", "18:90", "Realistic distribution age `min:max`")
+	fs.IntVar(&nameCardinality, "name-cardinality", 10000, "Realistic distribution number of distinct names")
+	fs.Float64Var(&skew, "skew", 1.0, "Realistic distribution Zipf exponent for names, addresses and tags")
+
 	fs.StringVar(&workload, "workload", "insert", "Workload name")
 	fs.Uint64Var(&opsMax, "ops", 0, "Number of `operations` to perform (0 == unlimited)")
 	fs.Uint64Var(&numWorkers, "workers", 30, "Number of concurrent workers")
 	fs.DurationVar(&timeout, "timeout", time.Duration(0), "Stop if runtime exceeds `d` (0 == unlimited, valid suffixes: s,m,h")
+
+	fs.StringVar(&indexes, "indexes", "age", "Comma separated `list` of optional indexes to create during setup (age, gin, tags, addresses, phone, phone-column, balance, dob)")
+	fs.StringVar(&ginOpClass, "gin-opclass", "jsonb_ops", "Postgres GIN index operator class (jsonb_ops, jsonb_path_ops)")
+	fs.IntVar(&fillFactor, "fillfactor", 0, "Postgres table fillfactor used during setup (0 == server default)")
+	fs.StringVar(&compression, "compression", "", "Block compressor (MongoDB) or column compression (Postgres) used during setup")
+	fs.DurationVar(&partitionInterval, "partition-interval", 0, "Partition the Postgres events table by time during setup, one partition per `interval` (0 == unpartitioned)")
+	fs.DurationVar(&eventTTL, "event-ttl", 0, "Expire MongoDB events older than `d` with a TTL index created during setup (0 == no expiry)")
+
+	fs.StringVar(&workloadOpts.rangeField, "range-field", "age", "Field queried by read-range (age, balance, counter)")
+	fs.StringVar(&workloadOpts.rangeBounds, "range-bounds", "45:75", "read-range bounds: fixed `lo:hi`, \"random\" or a target selectivity such as \"5%\"")
+	fs.Uint64Var(&workloadOpts.rangeLimit, "range-limit", 0, "Maximum number of records returned by read-range (0 == unlimited)")
+	fs.StringVar(&workloadOpts.rangeSort, "range-sort", "none", "Sort order of read-range results (none, asc, desc)")
+	fs.StringVar(&workloadOpts.sortField, "sort-field", "balance", "Field ordered by the sort workload (balance, dob)")
+	fs.StringVar(&workloadOpts.sortOrder, "sort-order", "desc", "Sort order of the sort workload (asc, desc)")
+	fs.Uint64Var(&workloadOpts.sortLimit, "sort-limit", 100, "Maximum number of records returned by the sort workload (0 == unlimited)")
+	fs.BoolVar(&workloadOpts.sortFiltered, "sort-filtered", false, "Only sort enabled records in the sort workload")
+	fs.BoolVar(&workloadOpts.sortDisk, "sort-disk", false, "Allow MongoDB to sort using disk in the sort workload (uses an aggregation pipeline)")
+	fs.Uint64Var(&workloadOpts.pages, "pages", 100, "Number of pages read by each call of the paginate workloads")
+	fs.Uint64Var(&workloadOpts.pageSize, "page-size", 20, "Number of records per page read by the paginate workloads")
+	fs.Uint64Var(&workloadOpts.topN, "top-n", 10, "Number of records returned by aggregate-top-counter")
+	fs.StringVar(&workloadOpts.growthSize, "growth-size", "1kb", "Padding appended per call by the grow-padding workloads (see -padding for the valid sizes)")
+	fs.StringVar(&workloadOpts.isolation, "isolation", "", "Postgres transaction isolation level of the transfer workloads (read-committed, repeatable-read, serializable), defaults to the isolation of the connection string")
+	fs.IntVar(&workloadOpts.txRetries, "tx-retries", 10, "Number of times a conflicting transaction is retried by the transfer workloads")
+	fs.Uint64Var(&workloadOpts.hotKeys, "hot-keys", 10, "Number of records incremented by increment-hot (IDs 1 to `n`)")
+	fs.IntVar(&workloadOpts.series, "series", 100, "Number of event streams in the timeseries workload")
+	fs.DurationVar(&workloadOpts.window, "window", 5*time.Minute, "Duration of recent events read by the timeseries workload queries")
+	fs.DurationVar(&workloadOpts.ttl, "ttl", time.Minute, "Lifetime of the records inserted by the expire workload")
+	fs.DurationVar(&workloadOpts.purgeInterval, "purge-interval", 10*time.Second, "How often the expire workload deletes expired records in Postgres")
+	fs.DurationVar(&workloadOpts.sizeInterval, "size-interval", 10*time.Second, "How often the expire and timeseries workloads sample the table/collection size")
+	fs.DurationVar(&workloadOpts.notifyGrace, "notify-grace", time.Second, "Time to wait after the notify workload for outstanding notifications")
+
+	fs.BoolVar(&workloadOpts.verifyReads, "verify", false, "Check the records read by the insert, update and select workloads match those written during the run")
+	fs.BoolVar(&workloadOpts.audit, "audit", false, "Audit the stored records after the run, comparing them with the records written")
+
+	fs.StringVar(&compareEndpoint, "compare", "", "Connection string of the second database checked by the compare command")
+	fs.Uint64Var(&compareRecords, "compare-records", 10000, "Number of records loaded into both databases by the compare command")
+
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Build %s (%s)\n\n", versionTag, versionDate)
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [run|setup|teardown|audit|compare]\n\n", os.Args[0])
 		fs.PrintDefaults()
 
 		var info = `
+Commands:
+	run:
+		Run the workload (default)
+	setup:
+		Create the table/collection and indexes
+	teardown:
+		Drop the table/collection and all it's indexes
+	audit:
+		Scan the table/collection, checking every record decodes and
+		counting duplicate and missing IDs (use -audit to also compare
+		the records with those written by a run)
+	compare:
+		Load the same -compare-records records into the empty
+		tables/collections of both -connect and -compare, and check the
+		aggregate workloads return the same results from both
+
 Available workloads:
 	insert:
 		Insert records with a monotonically increasing ID
@@ -69,11 +149,140 @@ Available workloads:
 	update-uniform:
 		Update a random record
 	read-range:
-		Perform a range query on the age field (age > 45 AND age < 75)
+		Perform a range query on the age field (age > 45 AND age < 75) - the
+		field, bounds, limit and sort order are configurable with the
+		-range-* flags, and the number of records returned is recorded
+	select-contains:
+		Read all enabled records with a random tag (Postgres: @> containment)
+	select-tag-exists:
+		Read all records with a random tag (Postgres: ? existence)
+	select-tag-any:
+		Read all records with either of two random tags (Postgres: ?| existence)
+	select-path-address:
+		Read all records with a random address number (Postgres: @? jsonpath)
+	select-path-address-func:
+		Same as select-path-address (Postgres: jsonb_path_exists, no index use)
+	select-path-balance:
+		Read all records with a balance in a random 0.01 wide range (Postgres: @@ jsonpath)
+	select-path-project:
+		Read the address lines of a random record (Postgres: jsonb_path_query_array)
+	select-nested-line:
+		Read all records with an address on a random street (MongoDB:
+		addresses.line1, Postgres: data->'addresses' @>)
+	select-nested-match:
+		Read all records with an address matching a random number and street
+		(MongoDB: $elemMatch, Postgres: data->'addresses' @>)
+	select-nested-elements:
+		Same as select-nested-match (Postgres: jsonb_array_elements, no index use)
+	select-phone-uniform:
+		Read a random record by it's phone number
+	select-phone-zipfian:
+		Same as select-phone-uniform, weighted towards the most recent
+	select-phone-covered-uniform:
+		Check a random phone number exists, reading only the indexed field
+		(Postgres: requires the phone-column index)
+	select-phone-covered-zipfian:
+		Same as select-phone-covered-uniform, weighted towards the most recent
+	select-fields-uniform:
+		Read the name and balance of a random record (Postgres: data->> columns)
+	select-fields-zipfian:
+		Same as select-fields-uniform, weighted towards the most recent
+	select-fields-object-uniform:
+		Same as select-fields-uniform (Postgres: jsonb_build_object)
+	select-fields-object-zipfian:
+		Same as select-fields-object-uniform, weighted towards the most recent
+	sort:
+		Read records ordered by a non-key field - the field, order, limit and
+		filter are configurable with the -sort-* flags. The sort plan is
+		logged before starting, and failed calls (such as MongoDB exceeding
+		it's in-memory sort limit) are counted
+	paginate-offset:
+		Read -pages pages of -page-size records ordered by ID, skipping
+		previous pages with OFFSET (MongoDB: skip) - the latency of each page
+		and of the whole scan is recorded
+	paginate-keyset:
+		Same as paginate-offset, starting each page after the last ID of the
+		previous page (WHERE id > last ORDER BY id LIMIT)
+	aggregate-count-enabled:
+		Count the records grouped by the enabled field
+	aggregate-avg-balance:
+		Average the balance of the records grouped into 10 year age buckets
+	aggregate-top-counter:
+		Read the -top-n records with the highest counter
+	push-address-uniform:
+		Append an address to a random record (MongoDB: $push, Postgres: ||)
+	push-address-zipfian:
+		Same as push-address-uniform, weighted towards the highest IDs
+	grow-padding-uniform:
+		Append -growth-size bytes of padding to a random record
+	grow-padding-zipfian:
+		Same as grow-padding-uniform, weighted towards the highest IDs
+	transfer-uniform:
+		Move balance between two random records in a transaction (MongoDB:
+		mgo/txn, Postgres: -isolation or the dial string isolation),
+		retrying conflicts up to -tx-retries times
+	transfer-zipfian:
+		Same as transfer-uniform, weighted towards the highest IDs
+	increment-hot:
+		Increment the counter of one of -hot-keys records (MongoDB: $inc,
+		Postgres: jsonb_set), checking for lost updates after the run and
+		sampling the operations waiting on a lock during it
+	queue:
+		Enqueue a job, claim the oldest pending job (MongoDB: findAndModify,
+		Postgres: FOR UPDATE SKIP LOCKED) and complete the claimed job,
+		recording the time each job was queued and checking for double claims
+	timeseries:
+		Append an event to one of -series event streams, read the last -window
+		of events for a random stream and roll them up per minute, sampling
+		the table size, ingest rate and query latency every -size-interval
+	expire:
+		Insert records that expire after -ttl (MongoDB: TTL index, Postgres:
+		DELETE every -purge-interval), sampling the table size every
+		-size-interval
+	notify:
+		Insert and then update a record, measuring the delay until a
+		subscriber is notified of each write (MongoDB: oplog tailing,
+		Postgres: LISTEN/NOTIFY) and counting missed notifications
+
+Record schemas:
+	By default records are a built-in "person" document (see the -padding,
+	-padding-type, -compressibility and -distribution flags).
+
+	The padding size can vary per record, drawn from a uniform, normal or
+	log-normal distribution, or a histogram file with a "size,count" line per
+	bucket (such as "4kb,1200"). The size of each inserted document is recorded
+	in bytes.
+
+	Use -schema to generate documents from a JSON Schema-like file instead,
+	supporting the object, array, string, integer, number and boolean types -
+	properties not listed in "required" are present in approximately half of
+	the documents.
+
+	Example: {"type": "object", "properties": {"sku": {"type": "string"}}}
+
+	Workloads querying person fields (age, tags, addresses, etc.) assume the
+	built-in person record.
 
 Postgres dial string parameters:
 	See https://godoc.org/github.com/lib/pq#hdr-Connection_String_Parameters
 
+	In addition to the driver parameters there are several more flags for
+	specifying session parameters, applied to every pooled connection:
+
+	synchronousCommit: (docs: https://www.postgresql.org/docs/current/runtime-config-wal.html#GUC-SYNCHRONOUS-COMMIT)
+		off / local / remote_write / on / remote_apply
+	isolation: (docs: https://www.postgresql.org/docs/current/transaction-iso.html)
+		read committed / repeatable read / serializable (used by the
+		transfer workloads unless -isolation is set)
+	statementTimeout:
+		<duration> (such as 5s, or a number of milliseconds - at least 1ms,
+		or 0 to disable the timeout)
+	applicationName:
+		<name>
+
+	The default values of the above are the server defaults. The settings in
+	effect are included in the table statistics.
+
 	Example: "postgres://localhost/test?sslmode=disable;binary_parameters=yes"
 
 Mongo dial string parameters:
@@ -88,6 +297,17 @@ Mongo dial string parameters:
 		true / false
 	fsync: (docs: https://docs.mongodb.com/manual/reference/command/fsync/)
 		true / false
+	readPreference: (docs: https://docs.mongodb.com/manual/core/read-preference/)
+		primary / primaryPreferred / secondary / secondaryPreferred / nearest
+		(with optional readPreferenceTags)
+	maxStalenessSeconds:
+		<number> of at least 10 - unlike MongoDB, all reads fall back to the
+		primary while any secondary lags by more than the maximum staleness,
+		checked every 10s during the run command only
+	reportServers:
+		true / false - count the reads served by each server (identified
+		with an isMaster command sent after each read, outside of the
+		measured latency)
 	
 	The default values of the above are the defaults specified by MonogDB.
 
@@ -97,38 +317,103 @@ Mongo dial string parameters:
 		fmt.Fprintf(os.Stderr, "\n%s\n", info)
 	}
 	fs.Parse(os.Args[1:])
-	if endpoint == "" {
+	if endpoint == "" || fs.NArg() > 1 {
 		fs.Usage()
 		os.Exit(1)
 	}
+	command = fs.Arg(0)
 }
 
 func main() {
-	var histW = ioutil.Discard
-	if histPath != "" {
-		f, err := os.Create(histPath)
-		if err != nil {
-			log.Fatal(err)
+	parseFlags()
+
+	// Get the correct provider for this DB type
+	db, err := getDB(endpoint, tableName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch command {
+	case "", "run", "audit", "compare":
+	case "setup":
+		if err := setup(db); err != nil {
+			log.Fatalf("setup: %v", err)
 		}
-		defer f.Close()
-		histW = f
+		return
+	case "teardown":
+		if err := db.Teardown(); err != nil {
+			log.Fatalf("teardown: %v", err)
+		}
+		return
+	default:
+		log.Fatalf("unknown command %q, valid: run setup teardown audit compare", command)
 	}
 
-	// Parse the record padding
-	var padding datasize.ByteSize
-	if err := padding.UnmarshalText([]byte(paddingSize)); err != nil {
+	// Configure the record type
+	pad, err := record.NewPaddingGenerator(record.PaddingType(paddingType), compressibility)
+	if err != nil {
 		log.Fatalf("padding: %v", err)
 	}
+	workloadOpts.padding = pad
 
-	// Get the correct provider for this DB type
-	db, err := getDB(endpoint, tableName)
+	records, err := getRecordSource(pad)
 	if err != nil {
 		log.Fatal(err)
 	}
+	workloadOpts.records = records
+
+	if command == "audit" {
+		a := &auditor{db: db, empty: records.New()}
+		rows, err := a.audit()
+		if err != nil {
+			log.Fatalf("audit: %v", err)
+		}
+
+		fmt.Printf("Audit:\n")
+		for _, r := range rows {
+			fmt.Printf("\t%s\t%s\n", r[0], r[1])
+		}
+		return
+	}
+
+	if command == "compare" {
+		if compareEndpoint == "" {
+			log.Fatal("compare: no -compare connection string")
+		}
+		other, err := getDB(compareEndpoint, tableName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		rows, mismatched, err := compareAggregates(db, other, records, compareRecords, workloadOpts.topN)
+		if err != nil {
+			log.Fatalf("compare: %v", err)
+		}
+
+		fmt.Printf("Compare:\n")
+		for _, r := range rows {
+			fmt.Printf("\t%s\t%s\n", r[0], r[1])
+		}
+		if mismatched {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var histW = ioutil.Discard
+	if histPath != "" {
+		f, err := os.Create(histPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		histW = f
+	}
 
 	// Create the work plan
-	dbplan := plan.New(opsMax, padding.Bytes())
-	if err := setWorkload(workload, dbplan, db); err != nil {
+	dbplan := plan.New(opsMax, records)
+	verify, err := setWorkload(workload, dbplan, db, workloadOpts)
+	if err != nil {
 		log.Fatal(err)
 	}
 
@@ -155,11 +440,34 @@ func main() {
 		}()
 	}
 
+	// Start any background monitoring of the database for the run
+	stopMonitor := func() {}
+	if m, ok := db.(monitor); ok {
+		stopMonitor = m.StartMonitor()
+	}
+
 	// Go!
 	results := dbplan.Run(numWorkers, os.Stdout)
+	stopMonitor()
+
+	// Collect the table statistics after the run
+	stats, err := db.Stats()
+	if err != nil {
+		log.Printf("error reading table statistics: %v", err)
+	}
+
+	// Check the resulting data for workloads that verify their results
+	if verify != nil {
+		rows, extra, err := verify()
+		if err != nil {
+			log.Printf("error verifying results: %v", err)
+		}
+		stats = append(stats, rows...)
+		results = append(results, extra...)
+	}
 
 	// Output the latency histograms as CSV files to histW.
-	reportHistograms(histW, results)
+	reportHistograms(histW, results, stats)
 }
 
 // getDB parses endpoint and returns a database provider based on the scheme.
@@ -193,8 +501,87 @@ func getDB(endpoint, tableName string) (dbProvider, error) {
 	return provider, nil
 }
 
-// reportHistograms writes runtime configuration and results to w as a CSV file.
-func reportHistograms(w io.Writer, results []plan.Result) {
+// getRecordSource returns a record.Source generating documents from the schema
+// file if provided, or a record.Person with padding generated by pad otherwise.
+func getRecordSource(pad *record.PaddingGenerator) (record.Source, error) {
+	if schemaPath != "" {
+		f, err := os.Open(schemaPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		s, err := record.ParseSchema(f)
+		if err != nil {
+			return nil, fmt.Errorf("schema: %v", err)
+		}
+		return &record.SchemaSource{Schema: s}, nil
+	}
+
+	// Parse the record padding size distribution
+	size, err := record.ParseSize(paddingSize)
+	if err != nil {
+		return nil, fmt.Errorf("padding: %v", err)
+	}
+
+	// Configure the record field value distribution
+	dist, err := getDistribution()
+	if err != nil {
+		return nil, fmt.Errorf("distribution: %v", err)
+	}
+
+	return &record.PersonSource{
+		PaddingSize:  size,
+		Padding:      pad,
+		Distribution: dist,
+	}, nil
+}
+
+// getDistribution returns the record field value distribution configured by
+// the distribution flags, or nil for uniformly random values.
+func getDistribution() (*record.Distribution, error) {
+	switch distribution {
+	case "random":
+		return nil, nil
+	case "realistic":
+	default:
+		return nil, fmt.Errorf("unknown distribution %q, valid: random realistic", distribution)
+	}
+
+	var minAge, maxAge int
+	if _, err := fmt.Sscanf(ageRange, "%d:%d", &minAge, &maxAge); err != nil {
+		return nil, fmt.Errorf("invalid age range %q", ageRange)
+	}
+
+	return record.NewDistribution(record.DistributionOptions{
+		MinAge:          minAge,
+		MaxAge:          maxAge,
+		NameCardinality: nameCardinality,
+		Skew:            skew,
+	})
+}
+
+// setup creates the table/collection and indexes using the schema flags.
+func setup(db dbProvider) error {
+	idx, err := schema.ParseIndexes(indexes)
+	if err != nil {
+		return err
+	}
+
+	return db.Setup(schema.Options{
+		Indexes:     idx,
+		GINOpClass:  ginOpClass,
+		FillFactor:  fillFactor,
+		Compression: compression,
+
+		PartitionInterval: partitionInterval,
+		EventTTL:          eventTTL,
+	})
+}
+
+// reportHistograms writes runtime configuration, table statistics and results
+// to w as a CSV file.
+func reportHistograms(w io.Writer, results []plan.Result, stats [][]string) {
 	// Print some run statistics
 	cw := csv.NewWriter(w)
 	cw.WriteAll([][]string{
@@ -203,14 +590,57 @@ func reportHistograms(w io.Writer, results []plan.Result) {
 		{"RecordLimit:", strconv.FormatUint(opsMax, 10)},
 		{"Workers:", strconv.FormatUint(numWorkers, 10)},
 		{"PaddingSize:", paddingSize},
+		{"PaddingType:", paddingType},
+		{"Compressibility:", strconv.FormatFloat(compressibility, 'f', -1, 64)},
+		{"Schema:", schemaPath},
+		{"Distribution:", distribution},
 		{"Workload:", workload},
+		{"RangeField:", workloadOpts.rangeField},
+		{"RangeBounds:", workloadOpts.rangeBounds},
+		{"RangeLimit:", strconv.FormatUint(workloadOpts.rangeLimit, 10)},
+		{"RangeSort:", workloadOpts.rangeSort},
+		{"SortField:", workloadOpts.sortField},
+		{"SortOrder:", workloadOpts.sortOrder},
+		{"SortLimit:", strconv.FormatUint(workloadOpts.sortLimit, 10)},
+		{"SortFiltered:", strconv.FormatBool(workloadOpts.sortFiltered)},
+		{"SortDisk:", strconv.FormatBool(workloadOpts.sortDisk)},
+		{"Pages:", strconv.FormatUint(workloadOpts.pages, 10)},
+		{"PageSize:", strconv.FormatUint(workloadOpts.pageSize, 10)},
+		{"TopN:", strconv.FormatUint(workloadOpts.topN, 10)},
+		{"GrowthSize:", workloadOpts.growthSize},
+		{"Isolation:", workloadOpts.isolation},
+		{"TxRetries:", strconv.Itoa(workloadOpts.txRetries)},
+		{"HotKeys:", strconv.FormatUint(workloadOpts.hotKeys, 10)},
+		{"Series:", strconv.Itoa(workloadOpts.series)},
+		{"Window:", workloadOpts.window.String()},
+		{"TTL:", workloadOpts.ttl.String()},
+		{"PurgeInterval:", workloadOpts.purgeInterval.String()},
+		{"SizeInterval:", workloadOpts.sizeInterval.String()},
+		{"NotifyGrace:", workloadOpts.notifyGrace.String()},
+		{"Verify:", strconv.FormatBool(workloadOpts.verifyReads)},
+		{"Audit:", strconv.FormatBool(workloadOpts.audit)},
 		{},
 	})
 	defer cw.Flush()
 
+	if len(stats) > 0 {
+		fmt.Printf("\nTable statistics:\n")
+		for _, s := range stats {
+			fmt.Printf("\t%s\t%s\n", s[0], s[1])
+		}
+
+		cw.WriteAll(append(stats, []string{}))
+	}
+
 	for _, op := range results {
+		if op.Failed > 0 {
+			fmt.Printf("\n%s failed calls: %d\n", op.Name, op.Failed)
+			fmt.Fprintf(w, "\n%s failed calls,%d\n", op.Name, op.Failed)
+		}
+
+		// Operations that never succeeded have nothing to report
 		if op.Histogram.Count == 0 {
-			return
+			continue
 		}
 
 		// Print to stdout
@@ -222,5 +652,17 @@ func reportHistograms(w io.Writer, results []plan.Result) {
 		if err := op.Histogram.WriteCSV(w); err != nil {
 			log.Printf("error writing histogram: %v", err)
 		}
+
+		if op.Counts == nil || op.Counts.Count == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s %s:\n", op.Name, op.Unit)
+		op.Counts.Print(os.Stdout)
+
+		fmt.Fprintf(w, "\n%s %s\n", op.Name, op.Unit)
+		if err := op.Counts.WriteCSV(w); err != nil {
+			log.Printf("error writing histogram: %v", err)
+		}
 	}
 }
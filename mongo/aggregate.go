@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// CountEnabled counts the records grouped by the enabled field with a $group
+// pipeline, returning the number of groups.
+func (p *FuncProvider) CountEnabled(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	out, err := p.EnabledCounts()
+	if err == nil {
+		err = query.VerifyEnabledCounts(out)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0, false
+	}
+
+	return uint64(len(out)), true
+}
+
+// EnabledCounts returns the number of records with each value of the enabled
+// field, ignoring records without it.
+func (p *FuncProvider) EnabledCounts() ([]query.EnabledCount, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	pipe := conn.DB("").C(p.Collection).Pipe([]bson.M{
+		{"$match": bson.M{"enabled": bson.M{"$exists": true}}},
+		{"$group": bson.M{
+			"_id":   "$enabled",
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+
+	var out []query.EnabledCount
+	if err := pipe.All(&out); err != nil {
+		return nil, err
+	}
+
+	p.recordServer(conn)
+
+	return out, nil
+}
+
+// AvgBalanceByAge calculates the average balance of the records in each
+// query.AgeBucketWidth wide age bucket with a $group pipeline, returning the
+// number of buckets.
+func (p *FuncProvider) AvgBalanceByAge(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	out, err := p.AgeBuckets()
+	if err == nil {
+		err = query.VerifyAgeBuckets(out)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0, false
+	}
+
+	return uint64(len(out)), true
+}
+
+// AgeBuckets returns the average balance of the records in each
+// query.AgeBucketWidth wide age bucket ordered by age, ignoring records without
+// an age.
+func (p *FuncProvider) AgeBuckets() ([]query.AgeBucket, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	pipe := conn.DB("").C(p.Collection).Pipe([]bson.M{
+		{"$match": bson.M{"age": bson.M{"$exists": true}}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"$subtract": []interface{}{
+					"$age",
+					bson.M{"$mod": []interface{}{"$age", query.AgeBucketWidth}},
+				},
+			},
+			"avg": bson.M{"$avg": "$balance"},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	})
+
+	var out []query.AgeBucket
+	if err := pipe.All(&out); err != nil {
+		return nil, err
+	}
+
+	p.recordServer(conn)
+
+	return out, nil
+}
+
+// TopCounters returns a CountFunc fetching the IDs and counters of the n
+// records with the highest counter (ties are broken by the highest ID) with a
+// $sort and $limit pipeline, returning the number of records read.
+func (p *FuncProvider) TopCounters(n uint64) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		out, err := p.CounterRanks(n)
+		if err == nil {
+			err = query.VerifyTopCounters(out, n)
+		}
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return uint64(len(out)), true
+	}
+}
+
+// CounterRanks returns the IDs and counters of the n records with the highest
+// counter (ties are broken by the highest ID), ignoring records without a
+// counter.
+func (p *FuncProvider) CounterRanks(n uint64) ([]query.CounterRank, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	pipe := conn.DB("").C(p.Collection).Pipe([]bson.M{
+		{"$match": bson.M{"counter": bson.M{"$exists": true}}},
+		{"$sort": bson.D{
+			{Name: "counter", Value: -1},
+			{Name: "_id", Value: -1},
+		}},
+		{"$limit": n},
+		{"$project": bson.M{"counter": 1}},
+	})
+
+	var out []query.CounterRank
+	if err := pipe.All(&out); err != nil {
+		return nil, err
+	}
+
+	p.recordServer(conn)
+
+	return out, nil
+}
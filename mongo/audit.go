@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// CountRecords returns the number of records in the collection.
+func (p *FuncProvider) CountRecords() (uint64, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	n, err := conn.DB("").C(p.Collection).Count()
+	return uint64(n), err
+}
+
+// ScanRecords calls f for every record in the collection in ID order, with the
+// record decoded into a copy of into.Empty() and any decoding error, including
+// unknown or missing fields (see record.CheckFields).
+func (p *FuncProvider) ScanRecords(into record.Record, f func(record.Record, error)) error {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	iter := conn.DB("").C(p.Collection).Find(nil).Sort("_id").Iter()
+
+	var raw bson.Raw
+	for iter.Next(&raw) {
+		rec := into.Empty()
+		f(rec, decodeRecord(raw, rec))
+	}
+
+	return iter.Close()
+}
+
+// decodeRecord decodes the BSON encoded record raw into rec, checking it has
+// the fields of rec.
+func decodeRecord(raw bson.Raw, rec record.Record) error {
+	if err := raw.Unmarshal(rec); err != nil {
+		return err
+	}
+
+	var doc bson.RawD
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+
+	names := make([]string, len(doc))
+	for i := range doc {
+		names[i] = doc[i].Name
+	}
+	return record.CheckFields(rec, names)
+}
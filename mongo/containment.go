@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadContains fetches all enabled records with a random tag, equivalent to the
+// Postgres @> containment query.
+func (p *FuncProvider) ReadContains(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	filter := bson.M{
+		"enabled": true,
+		"tags":    record.RandomTag(data, rnd),
+	}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadTagExists fetches all records with a random tag, equivalent to the
+// Postgres ? existence query on the tags array.
+func (p *FuncProvider) ReadTagExists(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	filter := bson.M{"tags": record.RandomTag(data, rnd)}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadAnyTagExists fetches all records with either of two random tags,
+// equivalent to the Postgres ?| existence query on the tags array.
+func (p *FuncProvider) ReadAnyTagExists(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	filter := bson.M{
+		"tags": bson.M{
+			"$in": []string{record.RandomTag(data, rnd), record.RandomTag(data, rnd)},
+		},
+	}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
 	"github.com/domodwyer/mpjbt/record"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
@@ -22,30 +24,49 @@ import (
 type FuncProvider struct {
 	Session    *mgo.Session
 	Collection string
+
+	// tx counts the outcome of the transfer transactions.
+	tx plan.TxStats
+
+	// servers counts the reads served by each server if reportServers is set
+	// in the dial string, otherwise nil.
+	servers *serverCounts
+
+	// staleness is the monitor for maxStalenessSeconds if set in the dial
+	// string, otherwise nil.
+	staleness *stalenessMonitor
 }
 
 // InsertRecord generates a new random record and inserts it with an ID provided
-// by id.GetNew
-func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+// by id.GetNew, returning the size of the BSON encoded record in bytes.
+//
+// The record is encoded before calling Insert to measure it's size - mgo does
+// not re-encode a bson.Raw document, so the record is only encoded once.
+func (p *FuncProvider) InsertRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
 	conn := p.Session.Copy()
 	defer conn.Close()
 
 	data.Randomise(rnd)
-	data.ID = id.GetNew()
+	data.SetID(id.GetNew())
 
-	if err := conn.DB("").C(p.Collection).Insert(data); err != nil {
+	doc, err := bson.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := conn.DB("").C(p.Collection).Insert(bson.Raw{Kind: 0x03, Data: doc}); err != nil {
 		log.Println(err)
-		return false
+		return 0, false
 	}
 
-	return true
+	return uint64(len(doc)), true
 }
 
 // UpdateRecord attempts to update the record with ID returned by
 // id.GetExisting.
 //
 // The balance field is changed to a random value from rnd.
-func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+func (p *FuncProvider) UpdateRecord(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
 	conn := p.Session.Copy()
 	defer conn.Close()
 
@@ -64,64 +85,140 @@ func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *r
 
 // ReadRecord attempts to fetch the record with an ID returned by
 // id.GetExisting.
-func (p *FuncProvider) ReadRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+//
+// If reportServers is set in the dial string, the server that served the read
+// is asked for it's address in the background after the read.
+func (p *FuncProvider) ReadRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
 	conn := p.Session.Copy()
 	defer conn.Close()
 
 	recordID := id.GetExisting()
-	query := conn.DB("").
-		C(p.Collection).
-		Find(bson.M{"_id": recordID}).
-		Limit(1)
 
-	var data = &record.Person{}
-	if err := query.One(data); err != nil {
+	found, err := fetchRecord(conn, p.Collection, data.Empty(), recordID)
+	if err == nil && !found {
+		err = mgo.ErrNotFound
+	}
+	if err != nil {
 		log.Println(recordID, err)
 		return false
 	}
 
+	p.recordServer(conn)
+
 	return true
 }
 
-// ReadRange performs a range query on the age field.
-//
-// THe query attempts to fetch all records where age is greater than 45 and less
-// than 75.
-func (p *FuncProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+// FetchRecord decodes the record with ID recordID into into, returning false if
+// it does not exist.
+func (p *FuncProvider) FetchRecord(into record.Record, recordID uint64) (bool, error) {
 	conn := p.Session.Copy()
 	defer conn.Close()
 
-	filter := bson.M{
-		"age": bson.M{
-			"$gt": 45,
-			"$lt": 75,
-		},
+	return fetchRecord(conn, p.Collection, into, recordID)
+}
+
+// fetchRecord decodes the record with ID recordID in coll into into using conn,
+// returning false if it does not exist.
+func fetchRecord(conn *mgo.Session, coll string, into record.Record, recordID uint64) (bool, error) {
+	err := conn.DB("").
+		C(coll).
+		Find(bson.M{"_id": recordID}).
+		Limit(1).
+		One(into)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// ReadRange returns a CountFunc performing the range query described by q,
+// returning the number of records read.
+func (p *FuncProvider) ReadRange(q *query.Range) plan.CountFunc {
+	return func(data record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		lo, hi := q.Bounds.Next(rnd)
+		filter := bson.M{
+			q.Field: bson.M{
+				"$gt": lo,
+				"$lt": hi,
+			},
+		}
+
+		find := conn.DB("").
+			C(p.Collection).
+			Find(filter)
+
+		switch q.Sort {
+		case query.Ascending:
+			find = find.Sort(q.Field)
+		case query.Descending:
+			find = find.Sort("-" + q.Field)
+		}
+		if q.Limit != 0 {
+			find = find.Limit(int(q.Limit))
+		}
+
+		n, err := readRecords(data.Empty(), find)
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		p.recordServer(conn)
+
+		return n, true
 	}
+}
+
+// SampleField returns the value of field from n random records.
+func (p *FuncProvider) SampleField(field string, n int) ([]float64, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
 
 	iter := conn.DB("").
 		C(p.Collection).
-		Find(filter).
+		Pipe([]bson.M{
+			{"$sample": bson.M{"size": n}},
+			{"$project": bson.M{"_id": 0, "v": "$" + field}},
+		}).
 		Iter()
 
-	var data = &record.Person{}
-	for iter.Next(data) {
-		// It does nothing!
-		//
-		// Make sure we actually read all the data, otherwise it's just the cost
-		// of getting a cursor and the inital batch.
+	var out []float64
+	var sample = struct {
+		V float64 `bson:"v"`
+	}{}
+	for iter.Next(&sample) {
+		out = append(out, sample.V)
 	}
 
-	if err := iter.Close(); err != nil {
-		log.Println(err)
-		return false
+	return out, iter.Close()
+}
+
+// readRecords runs find and decodes every returned record into into, returning
+// the number of records read.
+func readRecords(into record.Record, find *mgo.Query) (uint64, error) {
+	return readRecordsIter(into, find.Iter())
+}
+
+// readRecordsIter decodes every record returned by iter into into, returning
+// the number of records read.
+func readRecordsIter(into record.Record, iter *mgo.Iter) (uint64, error) {
+	var n uint64
+	for iter.Next(into) {
+		// Make sure we actually read all the data, otherwise it's just the cost
+		// of getting a cursor and the inital batch.
+		n++
 	}
 
-	return true
+	return n, iter.Close()
 }
 
 // ReadMostRecentRecord fetches the most recently inserted record by performing
 // a sort on the ID field, and limiting the results to a single record.
-func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+func (p *FuncProvider) ReadMostRecentRecord(data record.Record, _ idgen.Generator, _ *rand.Rand) bool {
 	conn := p.Session.Copy()
 	defer conn.Close()
 
@@ -131,12 +228,13 @@ func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator,
 		Sort("-_id").
 		Limit(1)
 
-	var data = &record.Person{}
-	if err := query.One(data); err != nil {
+	if err := query.One(data.Empty()); err != nil {
 		log.Println(err)
 		return false
 	}
 
+	p.recordServer(conn)
+
 	return true
 }
 
@@ -171,7 +269,16 @@ func (p *FuncProvider) GetMaxID() (uint64, error) {
 // 		writeConcern: majority/<number>
 // 		journal: true/false
 // 		fsync: true/false
+// 		maxStalenessSeconds: <number>
+// 		reportServers: true/false
 //
+// The readPreference and readPreferenceTags options are handled by mgo.
+//
+// mgo does not support maxStalenessSeconds, so it is approximated by switching
+// reads to the primary while any secondary lags by more than the maximum
+// staleness (see stalenessMonitor), checked only between StartMonitor and the
+// returned stop func. reportServers counts the reads served by each server in
+// the read workloads.
 func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	// Parse URL options and set flags
 	q := endpoint.Query()
@@ -223,6 +330,19 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	}
 	q.Del("fsync")
 
+	// Max staleness (readPreference itself is parsed by mgo)
+	maxStaleness, err := parseMaxStaleness(q.Get("maxStalenessSeconds"))
+	if err != nil {
+		return nil, err
+	}
+	q.Del("maxStalenessSeconds")
+
+	reportServers, err := parseReportServers(q.Get("reportServers"))
+	if err != nil {
+		return nil, err
+	}
+	q.Del("reportServers")
+
 	// Reset cleaned dial string to mgo compatible dial string
 	endpoint.RawQuery = q.Encode()
 
@@ -243,8 +363,30 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	runtime.GOMAXPROCS(4)
 
 	session.SetSafe(safe)
-	return &FuncProvider{
+
+	p := &FuncProvider{
 		Session:    session,
 		Collection: tableName,
-	}, nil
+	}
+
+	if reportServers {
+		p.servers = &serverCounts{reads: map[string]uint64{}}
+	}
+
+	if maxStaleness != 0 {
+		// The mode set by mgo from the readPreference option
+		mode := session.Mode()
+		if mode == mgo.Primary {
+			session.Close()
+			return nil, errors.New("maxStalenessSeconds cannot be used with the primary readPreference")
+		}
+
+		p.staleness = &stalenessMonitor{
+			session:      session,
+			mode:         mode,
+			maxStaleness: maxStaleness,
+		}
+	}
+
+	return p, nil
 }
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// expiringCollection returns the name of the collection used by the expire
+// workload.
+func (p *FuncProvider) expiringCollection() string {
+	return p.Collection + "_expiring"
+}
+
+// InsertExpiring returns a CountFunc inserting a random record that expires
+// after ttl, returning the size of the BSON encoded record in bytes.
+//
+// The record is nested under "record", with the expiry time stored in
+// "expires" as a BSON date for the TTL index created by Setup.
+func (p *FuncProvider) InsertExpiring(ttl time.Duration) plan.CountFunc {
+	return func(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		data.Randomise(rnd)
+		data.SetID(id.GetNew())
+
+		doc, err := bson.Marshal(data)
+		if err != nil {
+			panic(err)
+		}
+
+		err = conn.DB("").C(p.expiringCollection()).Insert(bson.M{
+			"expires": time.Now().Add(ttl),
+			"record":  bson.Raw{Kind: 0x03, Data: doc},
+		})
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return uint64(len(doc)), true
+	}
+}
+
+// PurgeExpired returns query.ErrServerExpiry - the server removes expired
+// records using the TTL index created by Setup.
+func (p *FuncProvider) PurgeExpired() (uint64, error) {
+	return 0, query.ErrServerExpiry
+}
+
+// ExpiringSize returns the number of records in the expiring collection, and
+// it's storage size in bytes including indexes.
+func (p *FuncProvider) ExpiringSize() (uint64, uint64, error) {
+	return p.collectionSize(p.expiringCollection())
+}
+
+// collectionSize returns the number of documents in the collection name, and
+// it's storage size in bytes including indexes.
+func (p *FuncProvider) collectionSize(name string) (uint64, uint64, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	var stats struct {
+		Count          uint64 `bson:"count"`
+		StorageSize    uint64 `bson:"storageSize"`
+		TotalIndexSize uint64 `bson:"totalIndexSize"`
+	}
+	err := conn.DB("").Run(bson.D{{Name: "collStats", Value: name}}, &stats)
+	return stats.Count, stats.StorageSize + stats.TotalIndexSize, err
+}
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// PushAddress appends a random address to the addresses array of the record
+// with ID returned by id.GetExisting using $push, increasing the document size.
+func (p *FuncProvider) PushAddress(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	recordID := id.GetExisting()
+	err := conn.DB("").C(p.Collection).Update(
+		bson.M{"_id": recordID},
+		bson.M{"$push": bson.M{"addresses": record.RandomAddress(rnd)}},
+	)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
+
+// GrowPadding returns a DoFunc appending padding generated by gen, sized by
+// size, to the growth array of the record with ID returned by id.GetExisting
+// using $push.
+func (p *FuncProvider) GrowPadding(size record.Size, gen *record.PaddingGenerator) plan.DoFunc {
+	return func(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		recordID := id.GetExisting()
+		err := conn.DB("").C(p.Collection).Update(
+			bson.M{"_id": recordID},
+			bson.M{"$push": bson.M{"growth": gen.Generate(size.Next(rnd), rnd)}},
+		)
+		if err != nil {
+			log.Println(recordID, err)
+			return false
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// IncrementCounter increments the counter field of the record with ID returned
+// by id.GetExisting using $inc.
+func (p *FuncProvider) IncrementCounter(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	recordID := id.GetExisting()
+	err := conn.DB("").C(p.Collection).Update(
+		bson.M{"_id": recordID},
+		bson.M{"$inc": bson.M{"counter": 1}},
+	)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
+
+// SumCounters returns the sum of the counter field of the records with an ID
+// less than or equal to maxID.
+func (p *FuncProvider) SumCounters(maxID uint64) (int64, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	pipe := conn.DB("").C(p.Collection).Pipe([]bson.M{
+		{"$match": bson.M{"_id": bson.M{"$lte": maxID}}},
+		{"$group": bson.M{"_id": nil, "sum": bson.M{"$sum": "$counter"}}},
+	})
+
+	var out struct {
+		Sum int64 `bson:"sum"`
+	}
+	if err := pipe.One(&out); err != nil {
+		return 0, err
+	}
+
+	return out.Sum, nil
+}
+
+// LockWaits returns the number of operations currently waiting to acquire a
+// lock.
+//
+// WiredTiger does not block on document level conflicts - the conflicting
+// write is retried (counted as a WriteConflict in Stats) - so only waits for
+// the collection, database or global locks are observed.
+func (p *FuncProvider) LockWaits() (uint64, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	var out struct {
+		InProg []bson.Raw `bson:"inprog"`
+	}
+	err := conn.DB("admin").Run(bson.D{
+		{Name: "currentOp", Value: 1},
+		{Name: "waitingForLock", Value: true},
+	}, &out)
+
+	return uint64(len(out.InProg)), err
+}
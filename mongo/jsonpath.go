@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// balanceWidth is the width of the balance range queried by ReadBalancePath.
+const balanceWidth = 0.01
+
+// ReadAddressPath fetches all records with an address number matching a random
+// value, equivalent to the Postgres @? jsonpath query.
+func (p *FuncProvider) ReadAddressPath(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	filter := bson.M{"addresses.number": rnd.Intn(256)}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadAddressPathFunc is the same as ReadAddressPath - MongoDB has no
+// equivalent of the operator/function distinction in Postgres.
+func (p *FuncProvider) ReadAddressPathFunc(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	return p.ReadAddressPath(data, id, rnd)
+}
+
+// ReadBalancePath fetches all records with a balance in a random range of
+// balanceWidth, equivalent to the Postgres @@ jsonpath query.
+func (p *FuncProvider) ReadBalancePath(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	lo := rnd.NormFloat64()
+	filter := bson.M{
+		"balance": bson.M{
+			"$gte": lo,
+			"$lt":  lo + balanceWidth,
+		},
+	}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadAddressLinesPath projects the first address line of each address in the
+// record with an ID returned by id.GetExisting, equivalent to the Postgres
+// jsonb_path_query_array query.
+func (p *FuncProvider) ReadAddressLinesPath(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	recordID := id.GetExisting()
+	query := conn.DB("").
+		C(p.Collection).
+		Find(bson.M{"_id": recordID}).
+		Select(bson.M{"_id": 0, "addresses.line1": 1}).
+		Limit(1)
+
+	var data = struct {
+		Addresses []struct {
+			Line1 string `bson:"line1"`
+		} `bson:"addresses"`
+	}{}
+
+	if err := query.One(&data); err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
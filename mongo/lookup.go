@@ -0,0 +1,61 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadByPhone fetches the record with the phone number of the ID returned by
+// id.GetExisting, returning the number of records read.
+func (p *FuncProvider) ReadByPhone(data record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	phone := record.PhoneNumber(id.GetExisting())
+	find := conn.DB("").C(p.Collection).Find(bson.M{"phone_number": phone})
+
+	n, err := readRecords(data.Empty(), find)
+	if err != nil {
+		log.Println(phone, err)
+		return 0, false
+	}
+
+	p.recordServer(conn)
+
+	return n, true
+}
+
+// ReadPhoneCovered checks a record with the phone number of the ID returned by
+// id.GetExisting exists, projecting only the phone_number field so the query
+// is covered by the schema.PhoneIndex, returning the number of records found.
+func (p *FuncProvider) ReadPhoneCovered(_ record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	phone := record.PhoneNumber(id.GetExisting())
+	iter := conn.DB("").
+		C(p.Collection).
+		Find(bson.M{"phone_number": phone}).
+		Select(bson.M{"_id": 0, "phone_number": 1}).
+		Iter()
+
+	var n uint64
+	var got struct {
+		PhoneNumber string `bson:"phone_number"`
+	}
+	for iter.Next(&got) {
+		n++
+	}
+	if err := iter.Close(); err != nil {
+		log.Println(phone, err)
+		return n, false
+	}
+
+	p.recordServer(conn)
+
+	return n, true
+}
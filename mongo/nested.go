@@ -0,0 +1,60 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadAddressLine fetches all records with an address on a random street,
+// equivalent to the Postgres @> containment query on the addresses array.
+func (p *FuncProvider) ReadAddressLine(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	filter := bson.M{"addresses.line1": record.RandomAddress(rnd).Line1}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadAddressMatch fetches all records with a single address matching both a
+// random number and street using $elemMatch.
+func (p *FuncProvider) ReadAddressMatch(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	addr := record.RandomAddress(rnd)
+	filter := bson.M{
+		"addresses": bson.M{
+			"$elemMatch": bson.M{
+				"number": addr.Number,
+				"line1":  addr.Line1,
+			},
+		},
+	}
+
+	if _, err := readRecords(data.Empty(), conn.DB("").C(p.Collection).Find(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadAddressElements is the same as ReadAddressMatch - MongoDB has no
+// equivalent of expanding the array with jsonb_array_elements in Postgres.
+func (p *FuncProvider) ReadAddressElements(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	return p.ReadAddressMatch(data, id, rnd)
+}
@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// notifyCollection returns the name of the collection used by the notify
+// workload.
+func (p *FuncProvider) notifyCollection() string {
+	return p.Collection + "_notify"
+}
+
+// InsertNotification inserts a notification with an ID provided by id.GetNew.
+func (p *FuncProvider) InsertNotification(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	n := query.NewNotification(id.GetNew(), 1)
+	if err := conn.DB("").C(p.notifyCollection()).Insert(&n); err != nil {
+		log.Println(n.ID, err)
+		return false
+	}
+
+	return true
+}
+
+// UpdateNotification replaces the notification with ID returned by
+// id.GetExisting with it's second version.
+//
+// The whole document is replaced so the oplog entry contains the document
+// rather than an update description.
+func (p *FuncProvider) UpdateNotification(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	n := query.NewNotification(id.GetExisting(), 2)
+	if err := conn.DB("").C(p.notifyCollection()).UpdateId(n.ID, &n); err != nil {
+		log.Println(n.ID, err)
+		return false
+	}
+
+	return true
+}
+
+// Subscribe tails the oplog for writes to the notify collection, calling f
+// from a single goroutine for each notification received until the returned
+// stop func is called.
+//
+// mgo does not support change streams, so the oplog (local.oplog.rs) is read
+// with a tailable cursor instead - this requires a replica set.
+func (p *FuncProvider) Subscribe(f func(query.Notification)) (func() error, error) {
+	conn := p.Session.Copy()
+	oplog := conn.DB("local").C("oplog.rs")
+
+	// Start after the most recent oplog entry
+	var last struct {
+		TS bson.MongoTimestamp `bson:"ts"`
+	}
+	if err := oplog.Find(nil).Sort("-$natural").One(&last); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	iter := oplog.Find(bson.M{
+		"ns": conn.DB("").Name + "." + p.notifyCollection(),
+		"ts": bson.M{"$gt": last.TS},
+	}).LogReplay().Tail(time.Second)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			var entry struct {
+				Op  string             `bson:"op"`
+				Doc query.Notification `bson:"o"`
+			}
+			if iter.Next(&entry) {
+				if entry.Op == "i" || entry.Op == "u" {
+					f(entry.Doc)
+				}
+				continue
+			}
+
+			if !iter.Timeout() {
+				log.Printf("oplog: %v", iter.Err())
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		wg.Wait()
+		defer conn.Close()
+		return iter.Close()
+	}, nil
+}
@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadPageOffset returns a PageFunc fetching pages of size records ordered by
+// _id, skipping the records in previous pages with Skip.
+//
+// The cursor is the number of records already read, so the database must walk
+// every record before the page.
+func (p *FuncProvider) ReadPageOffset(size uint64) plan.PageFunc {
+	return func(data record.Record, cursor uint64, _ *rand.Rand) (uint64, uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		find := conn.DB("").
+			C(p.Collection).
+			Find(bson.M{}).
+			Sort("_id").
+			Skip(int(cursor)).
+			Limit(int(size))
+
+		n, err := readRecords(data.Empty(), find)
+		if err != nil {
+			log.Println(err)
+			return 0, 0, false
+		}
+
+		p.recordServer(conn)
+
+		return cursor + n, n, true
+	}
+}
+
+// ReadPageKeyset returns a PageFunc fetching pages of size records ordered by
+// _id, starting after the last _id of the previous page.
+//
+// The cursor is the last _id read, allowing the _id index to seek directly to
+// the start of the page.
+func (p *FuncProvider) ReadPageKeyset(size uint64) plan.PageFunc {
+	return func(data record.Record, cursor uint64, _ *rand.Rand) (uint64, uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		find := conn.DB("").
+			C(p.Collection).
+			Find(bson.M{"_id": bson.M{"$gt": cursor}}).
+			Sort("_id").
+			Limit(int(size))
+
+		last := data.Empty()
+		n, err := readRecords(last, find)
+		if err != nil {
+			log.Println(err)
+			return 0, 0, false
+		}
+
+		p.recordServer(conn)
+
+		return last.GetID(), n, true
+	}
+}
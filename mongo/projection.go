@@ -0,0 +1,41 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadFields fetches the name and balance of the record with an ID returned by
+// id.GetExisting, projecting out the remaining fields on the server.
+func (p *FuncProvider) ReadFields(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	recordID := id.GetExisting()
+	find := conn.DB("").
+		C(p.Collection).
+		Find(bson.M{"_id": recordID}).
+		Select(bson.M{"_id": 0, "name": 1, "balance": 1}).
+		Limit(1)
+
+	var out query.Projection
+	if err := find.One(&out); err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	p.recordServer(conn)
+
+	return true
+}
+
+// ReadFieldsObject is the same as ReadFields - MongoDB always returns a
+// projection as a single document.
+func (p *FuncProvider) ReadFieldsObject(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	return p.ReadFields(data, id, rnd)
+}
@@ -0,0 +1,117 @@
+package mongo
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// jobsCollection returns the name of the collection used by the queue
+// workload.
+func (p *FuncProvider) jobsCollection() string {
+	return p.Collection + "_jobs"
+}
+
+// EnqueueJob inserts a pending job with an ID returned by id.GetNew, recording
+// the time it was enqueued.
+func (p *FuncProvider) EnqueueJob(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	err := conn.DB("").C(p.jobsCollection()).Insert(bson.M{
+		"_id":      id.GetNew(),
+		"state":    "pending",
+		"enqueued": time.Now().UnixNano(),
+	})
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ClaimJob claims the oldest pending job with token using findAndModify,
+// incrementing the number of times it has been claimed.
+//
+// If the queue is empty ClaimJob returns query.ErrQueueEmpty.
+func (p *FuncProvider) ClaimJob(token uint64) (query.Job, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	var job struct {
+		ID       uint64 `bson:"_id"`
+		Enqueued int64  `bson:"enqueued"`
+	}
+	_, err := conn.DB("").
+		C(p.jobsCollection()).
+		Find(bson.M{"state": "pending"}).
+		Sort("enqueued").
+		Apply(mgo.Change{
+			Update: bson.M{
+				"$set": bson.M{"state": "claimed", "claimed": time.Now().UnixNano(), "token": token},
+				"$inc": bson.M{"claims": 1},
+			},
+		}, &job)
+	if err == mgo.ErrNotFound {
+		return query.Job{Token: token}, query.ErrQueueEmpty
+	}
+	if err != nil {
+		return query.Job{Token: token}, err
+	}
+
+	return query.Job{
+		ID:     job.ID,
+		Token:  token,
+		Queued: time.Since(time.Unix(0, job.Enqueued)),
+	}, nil
+}
+
+// CompleteJob marks job as done if it is still claimed with job.Token.
+func (p *FuncProvider) CompleteJob(job query.Job) error {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	err := conn.DB("").C(p.jobsCollection()).Update(
+		bson.M{"_id": job.ID, "token": job.Token, "state": "claimed"},
+		bson.M{"$set": bson.M{"state": "done"}},
+	)
+	if err == mgo.ErrNotFound {
+		return errors.New("job not claimed with this token")
+	}
+	return err
+}
+
+// QueueStats counts the jobs in each state, and the jobs claimed more than
+// once, as name/value pairs.
+func (p *FuncProvider) QueueStats() ([][]string, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	coll := conn.DB("").C(p.jobsCollection())
+
+	rows := [][]string{}
+	for _, state := range []string{"pending", "claimed", "done"} {
+		n, err := coll.Find(bson.M{"state": state}).Count()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, []string{"Jobs" + strings.Title(state) + ":", strconv.Itoa(n)})
+	}
+
+	n, err := coll.Find(bson.M{"claims": bson.M{"$gt": 1}}).Count()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rows, []string{"DoubleClaims:", strconv.Itoa(n)}), nil
+}
@@ -0,0 +1,251 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// stalenessInterval is how often the replication lag is checked when
+// maxStalenessSeconds is set.
+const stalenessInterval = 10 * time.Second
+
+// parseReportServers returns true if the reportServers value s enables
+// reporting the server that served each read.
+func parseReportServers(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "false", "0":
+		return false, nil
+	case "true", "1":
+		return true, nil
+	}
+	return false, errors.New("unknown reportServers value")
+}
+
+// serverCounts counts the reads served by each server.
+type serverCounts struct {
+	// pending tracks the record calls still in progress.
+	pending sync.WaitGroup
+
+	mu    sync.Mutex
+	reads map[string]uint64
+}
+
+// record counts the read last served by conn against the server that served
+// it, without waiting for the server to be identified.
+//
+// In all modes but mgo.Eventual a session keeps using the same socket, so a
+// clone of conn sends the isMaster command to the server that served the read.
+// The command runs in the background so it is not included in the measured
+// latency of the read.
+func (s *serverCounts) record(conn *mgo.Session) {
+	clone := conn.Clone()
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		defer clone.Close()
+		s.identify(clone)
+	}()
+}
+
+// identify asks the server conn is using for it's address, and counts a read
+// against it.
+func (s *serverCounts) identify(conn *mgo.Session) {
+	var info struct {
+		Me string `bson:"me"`
+	}
+	if err := conn.Run("isMaster", &info); err != nil {
+		log.Printf("isMaster: %v", err)
+		return
+	}
+	if info.Me == "" {
+		// Standalone servers do not report their address
+		info.Me = "standalone"
+	}
+
+	s.mu.Lock()
+	s.reads[info.Me]++
+	s.mu.Unlock()
+}
+
+// recordServer counts the read last served by conn if reportServers is set in
+// the dial string.
+func (p *FuncProvider) recordServer(conn *mgo.Session) {
+	if p.servers != nil {
+		p.servers.record(conn)
+	}
+}
+
+// rows returns the number of reads served by each server as name/value pairs,
+// once all the reads recorded have been counted.
+func (s *serverCounts) rows() [][]string {
+	s.pending.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addrs := make([]string, 0, len(s.reads))
+	for addr := range s.reads {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	rows := make([][]string, 0, len(addrs))
+	for _, addr := range addrs {
+		rows = append(rows, []string{"ReadsFrom " + addr + ":", strconv.FormatUint(s.reads[addr], 10)})
+	}
+	return rows
+}
+
+// stalenessMonitor approximates the maxStalenessSeconds read preference option,
+// which mgo does not support.
+//
+// This deviates from maxStalenessSeconds: MongoDB excludes stale secondaries
+// from server selection (failing reads in the secondary mode if none are
+// eligible), but mgo cannot exclude individual secondaries. Instead, when any
+// secondary lags the primary by more than maxStaleness the session is switched
+// to mgo.Primary until they catch up, in every mode. The lag is checked every
+// stalenessInterval, so a stale secondary may serve reads until the next check.
+type stalenessMonitor struct {
+	session      *mgo.Session
+	mode         mgo.Mode
+	maxStaleness time.Duration
+
+	mu        sync.Mutex
+	fallbacks uint64
+	maxLag    time.Duration
+}
+
+// run checks the replication lag every stalenessInterval until stop is closed,
+// then restores the configured read preference.
+func (m *stalenessMonitor) run(stop <-chan struct{}) {
+	tick := time.NewTicker(stalenessInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			m.session.SetMode(m.mode, false)
+			return
+		case <-tick.C:
+		}
+
+		lag, err := m.lag()
+		if err != nil {
+			log.Printf("staleness check: %v", err)
+			continue
+		}
+
+		m.mu.Lock()
+		if lag > m.maxLag {
+			m.maxLag = lag
+		}
+		stale := lag > m.maxStaleness
+		if stale && m.session.Mode() != mgo.Primary {
+			m.fallbacks++
+		}
+		m.mu.Unlock()
+
+		if stale {
+			m.session.SetMode(mgo.Primary, false)
+		} else {
+			m.session.SetMode(m.mode, false)
+		}
+	}
+}
+
+// lag returns how far the most stale secondary is behind the primary.
+func (m *stalenessMonitor) lag() (time.Duration, error) {
+	conn := m.session.Copy()
+	defer conn.Close()
+
+	var status struct {
+		Members []struct {
+			StateStr   string    `bson:"stateStr"`
+			OptimeDate time.Time `bson:"optimeDate"`
+		} `bson:"members"`
+	}
+	if err := conn.DB("admin").Run(bson.D{{Name: "replSetGetStatus", Value: 1}}, &status); err != nil {
+		return 0, err
+	}
+
+	var primary time.Time
+	var oldest time.Time
+	for _, member := range status.Members {
+		switch member.StateStr {
+		case "PRIMARY":
+			primary = member.OptimeDate
+		case "SECONDARY":
+			if oldest.IsZero() || member.OptimeDate.Before(oldest) {
+				oldest = member.OptimeDate
+			}
+		}
+	}
+	if primary.IsZero() || oldest.IsZero() {
+		return 0, nil
+	}
+
+	return primary.Sub(oldest), nil
+}
+
+// rows returns the maximum lag seen, and the number of times reads fell back
+// to the primary, as name/value pairs.
+func (m *stalenessMonitor) rows() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return [][]string{
+		{"MaxReplicationLag:", m.maxLag.String()},
+		{"StalenessFallbacks:", strconv.FormatUint(m.fallbacks, 10)},
+	}
+}
+
+// StartMonitor starts the maxStalenessSeconds monitor if set in the dial
+// string, returning a func that stops it once it's last check completes.
+func (p *FuncProvider) StartMonitor() (stop func()) {
+	if p.staleness == nil {
+		return func() {}
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		p.staleness.run(quit)
+		close(done)
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+// parseMaxStaleness returns the duration of the maxStalenessSeconds value s, or
+// 0 if s is empty.
+//
+// Values below stalenessInterval are rejected, as the lag is not checked often
+// enough to enforce them.
+func parseMaxStaleness(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid maxStalenessSeconds value %q", s)
+	}
+
+	d := time.Duration(n) * time.Second
+	if d < stalenessInterval {
+		return 0, fmt.Errorf("maxStalenessSeconds must be at least %d (the staleness check interval)", int(stalenessInterval/time.Second))
+	}
+	return d, nil
+}
@@ -0,0 +1,60 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMaxStaleness(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "10", want: 10 * time.Second},
+		{in: "90", want: 90 * time.Second},
+		{in: "9", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "5s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMaxStaleness(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMaxStaleness(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseMaxStaleness(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseReportServers(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    bool
+		wantErr bool
+	}{
+		{in: "", want: false},
+		{in: "false", want: false},
+		{in: "0", want: false},
+		{in: "true", want: true},
+		{in: "TRUE", want: true},
+		{in: "1", want: true},
+		{in: "yes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseReportServers(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseReportServers(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseReportServers(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package mongo
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/domodwyer/mpjbt/schema"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Setup creates the collection and indexes described by opts.
+//
+// The _id index is created automatically by MongoDB. opts.FillFactor has no
+// MongoDB equivalent and is ignored. An index on the pending jobs is created in
+// the jobs collection for the queue workload, and an index on
+// the series and event time (and a TTL index if opts.EventTTL is set) in the
+// events collection for the time-series workload. A TTL index on the expiry
+// time is created in the expiring collection for the expire workload.
+func (p *FuncProvider) Setup(opts schema.Options) error {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	coll := conn.DB("").C(p.Collection)
+
+	info := &mgo.CollectionInfo{}
+	if opts.Compression != "" {
+		info.StorageEngine = bson.M{
+			"wiredTiger": bson.M{"configString": "block_compressor=" + opts.Compression},
+		}
+	}
+
+	log.Printf("creating collection %s", p.Collection)
+	if err := coll.Create(info); err != nil {
+		return err
+	}
+
+	if opts.Has(schema.AgeIndex) {
+		log.Printf("creating index %s_age", p.Collection)
+		err := coll.EnsureIndex(mgo.Index{
+			Name: p.Collection + "_age",
+			Key:  []string{"age"},
+			PartialFilter: bson.M{
+				"age": bson.M{
+					"$gt": 45,
+					"$lt": 75,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Has(schema.GINIndex) {
+		// mgo cannot express a wildcard key with EnsureIndex, so run the
+		// createIndexes command directly.
+		log.Printf("creating index %s_wildcard", p.Collection)
+		err := conn.DB("").Run(bson.D{
+			{Name: "createIndexes", Value: p.Collection},
+			{Name: "indexes", Value: []bson.M{{
+				"key":  bson.M{"$**": 1},
+				"name": p.Collection + "_wildcard",
+			}}},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Has(schema.TagsIndex) {
+		log.Printf("creating index %s_tags", p.Collection)
+		err := coll.EnsureIndex(mgo.Index{
+			Name: p.Collection + "_tags",
+			Key:  []string{"tags"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Has(schema.AddressesIndex) {
+		log.Printf("creating index %s_addresses", p.Collection)
+		err := coll.EnsureIndex(mgo.Index{
+			Name: p.Collection + "_addresses",
+			Key:  []string{"addresses.line1", "addresses.number"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Has(schema.PhoneIndex) || opts.Has(schema.PhoneColumnIndex) {
+		log.Printf("creating index %s_phone", p.Collection)
+		err := coll.EnsureIndex(mgo.Index{
+			Name: p.Collection + "_phone",
+			Key:  []string{"phone_number"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, field := range []schema.Index{schema.BalanceIndex, schema.DOBIndex} {
+		if !opts.Has(field) {
+			continue
+		}
+
+		log.Printf("creating index %s_%s", p.Collection, field)
+		err := coll.EnsureIndex(mgo.Index{
+			Name: p.Collection + "_" + string(field),
+			Key:  []string{string(field)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("creating index %s_enqueued", p.jobsCollection())
+	err := conn.DB("").C(p.jobsCollection()).EnsureIndex(mgo.Index{
+		Name: p.jobsCollection() + "_enqueued",
+		Key:  []string{"state", "enqueued"},
+	})
+	if err != nil {
+		return err
+	}
+
+	events := conn.DB("").C(p.eventsCollection())
+
+	log.Printf("creating index %s_series_ts", p.eventsCollection())
+	err = events.EnsureIndex(mgo.Index{
+		Name: p.eventsCollection() + "_series_ts",
+		Key:  []string{"series", "ts"},
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.EventTTL != 0 {
+		log.Printf("creating index %s_ttl", p.eventsCollection())
+		err := events.EnsureIndex(mgo.Index{
+			Name:        p.eventsCollection() + "_ttl",
+			Key:         []string{"ts"},
+			ExpireAfter: opts.EventTTL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The expires field holds the expiry time, so the TTL index expires records
+	// after 0 seconds - mgo omits a zero ExpireAfter, so the index is created
+	// with the createIndexes command.
+	log.Printf("creating index %s_expires", p.expiringCollection())
+	err = conn.DB("").Run(bson.D{
+		{Name: "createIndexes", Value: p.expiringCollection()},
+		{Name: "indexes", Value: []bson.M{{
+			"name":               p.expiringCollection() + "_expires",
+			"key":                bson.M{"expires": 1},
+			"expireAfterSeconds": 0,
+		}}},
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Teardown drops the collection and all it's indexes, the jobs, events,
+// expiring and notify collections and the collections used by the transfer
+// transactions.
+func (p *FuncProvider) Teardown() error {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	for _, name := range []string{p.Collection, p.jobsCollection(), p.eventsCollection(), p.expiringCollection(), p.notifyCollection(), p.Collection + "_txns", p.Collection + "_txns.stash"} {
+		err := conn.DB("").C(name).DropCollection()
+		if err != nil && err.Error() != "ns not found" {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats returns the collection size statistics reported by collStats as
+// name/value pairs, followed by the outcome of any transfer transactions.
+//
+// WriteConflicts is the number of times the server retried a write that
+// conflicted with a concurrent write to the same document, cumulative since
+// the server started. The reads served by each server and the replication lag
+// are appended if enabled in the dial string.
+func (p *FuncProvider) Stats() ([][]string, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	var stats bson.M
+	if err := conn.DB("").Run(bson.D{{Name: "collStats", Value: p.Collection}}, &stats); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{
+		{"Count:", fmt.Sprint(stats["count"])},
+		{"Size:", fmt.Sprint(stats["size"])},
+		{"AvgObjSize:", fmt.Sprint(stats["avgObjSize"])},
+		{"StorageSize:", fmt.Sprint(stats["storageSize"])},
+		{"TotalIndexSize:", fmt.Sprint(stats["totalIndexSize"])},
+	}
+
+	var status struct {
+		Metrics struct {
+			Operation struct {
+				WriteConflicts int64 `bson:"writeConflicts"`
+			} `bson:"operation"`
+		} `bson:"metrics"`
+	}
+	if err := conn.DB("admin").Run(bson.D{{Name: "serverStatus", Value: 1}}, &status); err != nil {
+		return nil, err
+	}
+	rows = append(rows, []string{"WriteConflicts:", fmt.Sprint(status.Metrics.Operation.WriteConflicts)})
+
+	if p.servers != nil {
+		rows = append(rows, p.servers.rows()...)
+	}
+	if p.staleness != nil {
+		rows = append(rows, p.staleness.rows()...)
+	}
+
+	return append(rows, p.tx.Rows()...), nil
+}
@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ReadSorted returns a CountFunc performing the sort query described by q,
+// returning the number of records read.
+//
+// If q.AllowDisk is true the query is run as an aggregation pipeline with
+// allowDiskUse, as mgo cannot set allowDiskUse on a find.
+func (p *FuncProvider) ReadSorted(q *query.TopN) plan.CountFunc {
+	return func(data record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		coll := conn.DB("").C(p.Collection)
+
+		var n uint64
+		var err error
+		if q.AllowDisk {
+			n, err = readRecordsIter(data.Empty(), coll.Pipe(sortPipeline(q)).AllowDiskUse().Iter())
+		} else {
+			key := q.Field
+			if q.Sort == query.Descending {
+				key = "-" + key
+			}
+			n, err = readRecords(data.Empty(), coll.Find(sortFilter(q)).Sort(key).Limit(int(q.Limit)))
+		}
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		p.recordServer(conn)
+
+		return n, true
+	}
+}
+
+// ExplainSort runs the sort query described by q with the explain command,
+// returning a description of each blocking sort stage and whether it used
+// disk.
+func (p *FuncProvider) ExplainSort(q *query.TopN) (string, error) {
+	conn := p.Session.Copy()
+	defer conn.Close()
+
+	var cmd bson.D
+	if q.AllowDisk {
+		cmd = bson.D{
+			{Name: "aggregate", Value: p.Collection},
+			{Name: "pipeline", Value: sortPipeline(q)},
+			{Name: "allowDiskUse", Value: true},
+			{Name: "cursor", Value: bson.M{}},
+		}
+	} else {
+		cmd = bson.D{
+			{Name: "find", Value: p.Collection},
+			{Name: "filter", Value: sortFilter(q)},
+			{Name: "sort", Value: bson.D{{Name: q.Field, Value: sortDirection(q)}}},
+		}
+		if q.Limit != 0 {
+			cmd = append(cmd, bson.DocElem{Name: "limit", Value: q.Limit})
+		}
+	}
+
+	var result bson.M
+	err := conn.DB("").Run(bson.D{
+		{Name: "explain", Value: cmd},
+		{Name: "verbosity", Value: "executionStats"},
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+
+	sorts := findSortStages(result)
+	if len(sorts) == 0 {
+		return "no sort (index order)", nil
+	}
+	return strings.Join(sorts, ", "), nil
+}
+
+// sortFilter returns the query filter for q.
+func sortFilter(q *query.TopN) bson.M {
+	if q.Filtered {
+		return bson.M{"enabled": true}
+	}
+	return bson.M{}
+}
+
+// sortDirection returns the sort key direction for q.
+func sortDirection(q *query.TopN) int {
+	if q.Sort == query.Descending {
+		return -1
+	}
+	return 1
+}
+
+// sortPipeline returns an aggregation pipeline equivalent to the find query for
+// q.
+func sortPipeline(q *query.TopN) []bson.M {
+	pipeline := []bson.M{
+		{"$match": sortFilter(q)},
+		{"$sort": bson.M{q.Field: sortDirection(q)}},
+	}
+	if q.Limit != 0 {
+		pipeline = append(pipeline, bson.M{"$limit": q.Limit})
+	}
+	return pipeline
+}
+
+// sortStats lists the fields of an explained sort stage worth reporting.
+var sortStats = []string{"memUsage", "memLimit", "totalDataSizeSorted", "totalDataSizeSortedBytesEstimate", "usedDisk", "spills"}
+
+// findSortStages returns a description of each SORT stage (find) or $sort stage
+// (aggregate) in the explain output v.
+//
+// The statistics of an aggregate $sort stage are held in the stage object
+// alongside the $sort key, not in the $sort document itself.
+func findSortStages(v interface{}) []string {
+	var out []string
+	switch v := v.(type) {
+	case bson.M:
+		_, isSort := v["$sort"]
+		if isSort || v["stage"] == "SORT" {
+			var stats []string
+			for _, k := range sortStats {
+				if val, ok := v[k]; ok {
+					stats = append(stats, fmt.Sprintf("%s=%v", k, val))
+				}
+			}
+			out = append(out, "SORT ("+strings.Join(stats, " ")+")")
+		}
+
+		// Visit the children in a stable order
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = append(out, findSortStages(v[k])...)
+		}
+
+	case []interface{}:
+		for _, e := range v {
+			out = append(out, findSortStages(e)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestFindSortStages(t *testing.T) {
+	tests := []struct {
+		name    string
+		explain bson.M
+		want    []string
+	}{
+		{
+			name: "find",
+			explain: bson.M{
+				"executionStats": bson.M{
+					"executionStages": bson.M{
+						"stage":    "SORT",
+						"memUsage": 42,
+						"memLimit": 100,
+						"inputStage": bson.M{
+							"stage": "COLLSCAN",
+						},
+					},
+				},
+			},
+			want: []string{"SORT (memUsage=42 memLimit=100)"},
+		},
+		{
+			name: "aggregate",
+			explain: bson.M{
+				"stages": []interface{}{
+					bson.M{"$cursor": bson.M{"queryPlanner": bson.M{}}},
+					bson.M{
+						"$sort": bson.M{
+							"sortKey": bson.M{"balance": -1},
+							"limit":   10,
+						},
+						"totalDataSizeSortedBytesEstimate": 2048,
+						"usedDisk":                         true,
+					},
+				},
+			},
+			want: []string{"SORT (totalDataSizeSortedBytesEstimate=2048 usedDisk=true)"},
+		},
+		{
+			name: "index order",
+			explain: bson.M{
+				"executionStats": bson.M{
+					"executionStages": bson.M{
+						"stage":      "LIMIT",
+						"inputStage": bson.M{"stage": "IXSCAN"},
+					},
+				},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSortStages(tt.explain)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
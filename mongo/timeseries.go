@@ -0,0 +1,132 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo/bson"
+)
+
+// event is a single time-series event.
+type event struct {
+	Series string            `bson:"series"`
+	TS     time.Time         `bson:"ts"`
+	Value  float64           `bson:"value"`
+	Tags   map[string]string `bson:"tags"`
+}
+
+// eventsCollection returns the name of the collection used by the time-series
+// workload.
+func (p *FuncProvider) eventsCollection() string {
+	return p.Collection + "_events"
+}
+
+// AppendEvent returns a DoFunc inserting an event with the current time into
+// a random series of ts.
+//
+// The timestamp is stored as a BSON date so it can be expired by a TTL index.
+func (p *FuncProvider) AppendEvent(ts query.TimeSeries) plan.DoFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		series := ts.RandomSeries(rnd)
+		err := conn.DB("").C(p.eventsCollection()).Insert(&event{
+			Series: series.Name,
+			TS:     time.Now(),
+			Value:  rnd.Float64(),
+			Tags:   series.Tags,
+		})
+		if err != nil {
+			log.Println(series.Name, err)
+			return false
+		}
+
+		return true
+	}
+}
+
+// ReadWindow returns a CountFunc reading the events of a random series of ts
+// within the last ts.Window, returning the number of events read.
+func (p *FuncProvider) ReadWindow(ts query.TimeSeries) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		series := ts.RandomSeries(rnd).Name
+		iter := conn.DB("").
+			C(p.eventsCollection()).
+			Find(windowFilter(series, ts.Window)).
+			Sort("ts").
+			Iter()
+
+		var n uint64
+		var e event
+		for iter.Next(&e) {
+			n++
+		}
+		if err := iter.Close(); err != nil {
+			log.Println(series, err)
+			return n, false
+		}
+
+		p.recordServer(conn)
+
+		return n, true
+	}
+}
+
+// RollupWindow returns a CountFunc computing the per-minute event count and
+// average value of a random series of ts within the last ts.Window, returning
+// the number of minutes read.
+func (p *FuncProvider) RollupWindow(ts query.TimeSeries) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		series := ts.RandomSeries(rnd).Name
+		pipe := conn.DB("").C(p.eventsCollection()).Pipe([]bson.M{
+			{"$match": windowFilter(series, ts.Window)},
+			{"$group": bson.M{
+				"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%dT%H:%M", "date": "$ts"}},
+				"count": bson.M{"$sum": 1},
+				"avg":   bson.M{"$avg": "$value"},
+			}},
+			{"$sort": bson.M{"_id": 1}},
+		})
+
+		var out []struct {
+			Minute string  `bson:"_id"`
+			Count  uint64  `bson:"count"`
+			Avg    float64 `bson:"avg"`
+		}
+		if err := pipe.All(&out); err != nil {
+			log.Println(series, err)
+			return 0, false
+		}
+
+		p.recordServer(conn)
+
+		return uint64(len(out)), true
+	}
+}
+
+// EventsSize returns the number of events in the events collection, and it's
+// storage size in bytes including indexes.
+func (p *FuncProvider) EventsSize() (uint64, uint64, error) {
+	return p.collectionSize(p.eventsCollection())
+}
+
+// windowFilter returns a filter matching the events of series within the last
+// window.
+func windowFilter(series string, window time.Duration) bson.M {
+	return bson.M{
+		"series": series,
+		"ts":     bson.M{"$gte": time.Now().Add(-window)},
+	}
+}
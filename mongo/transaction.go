@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/globalsign/mgo/txn"
+)
+
+// Transfer returns a CountFunc moving t.Amount of balance between two records
+// with IDs returned by id.GetExisting, returning the number of times the
+// transaction was retried.
+//
+// mgo does not support the multi-document transactions added in MongoDB 4.0,
+// so the client side mgo/txn runner is used instead, storing transactions in
+// the "<collection>_txns" collection. Both balances are read and then updated
+// in a txn asserting neither has changed since - if a concurrent transfer
+// changed either balance the transaction aborts and is retried, up to
+// t.MaxRetries times. t.Isolation is ignored.
+func (p *FuncProvider) Transfer(t query.Transfer) plan.CountFunc {
+	return func(_ record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		conn := p.Session.Copy()
+		defer conn.Close()
+
+		from, to := query.TransferIDs(id)
+
+		for retries := 0; ; retries++ {
+			err := p.transfer(conn, t.Amount, from, to)
+			if err == nil {
+				return uint64(retries), true
+			}
+
+			if err != txn.ErrAborted {
+				log.Println(from, to, err)
+				p.tx.Abort()
+				return uint64(retries), false
+			}
+
+			if retries == t.MaxRetries {
+				log.Println(from, to, "transaction aborted after", retries, "retries")
+				p.tx.Abort()
+				return uint64(retries), false
+			}
+			p.tx.Retry()
+		}
+	}
+}
+
+// transfer performs a single attempt of the transfer from one record to
+// another, returning txn.ErrAborted if either balance changed after it was
+// read.
+func (p *FuncProvider) transfer(conn *mgo.Session, amount float64, from, to uint64) error {
+	db := conn.DB("")
+	start := time.Now()
+
+	var balances []struct {
+		ID      uint64  `bson:"_id"`
+		Balance float64 `bson:"balance"`
+	}
+	err := db.C(p.Collection).
+		Find(bson.M{"_id": bson.M{"$in": []uint64{from, to}}}).
+		Select(bson.M{"balance": 1}).
+		All(&balances)
+	if err != nil {
+		return err
+	}
+	if len(balances) != 2 {
+		return mgo.ErrNotFound
+	}
+
+	ops := make([]txn.Op, 0, 2)
+	for _, b := range balances {
+		balance := b.Balance + amount
+		if b.ID == from {
+			balance = b.Balance - amount
+		}
+
+		ops = append(ops, txn.Op{
+			C:      p.Collection,
+			Id:     b.ID,
+			Assert: bson.M{"balance": b.Balance},
+			Update: bson.M{"$set": bson.M{"balance": balance}},
+		})
+	}
+
+	runner := txn.NewRunner(db.C(p.Collection + "_txns"))
+	if err := runner.Run(ops, "", nil); err != nil {
+		return err
+	}
+	p.tx.Commit(time.Since(start))
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/domodwyer/dstats"
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// notifier records the delay between writing a notification and a subscriber
+// receiving it for the notify workload, and checks every successful write was
+// received.
+type notifier struct {
+	written uint64 // atomic
+	grace   time.Duration
+	stop    func() error
+
+	latency *dstats.Histogram
+
+	// Only accessed by the subscriber goroutine until stopped.
+	child      *dstats.HistogramChild
+	received   map[query.Notification]struct{}
+	duplicates uint64
+}
+
+// startNotifier subscribes to the notifications written by db, waiting grace
+// after the run for outstanding notifications to be delivered.
+func startNotifier(db dbProvider, grace time.Duration) (*notifier, error) {
+	n := &notifier{
+		grace:    grace,
+		latency:  plan.NewLatencyHistogram(),
+		received: map[query.Notification]struct{}{},
+	}
+	n.child = n.latency.Split()
+
+	stop, err := db.Subscribe(n.receive)
+	if err != nil {
+		return nil, err
+	}
+	n.stop = stop
+
+	return n, nil
+}
+
+// receive records the delay of msg, and checks it has not been received
+// before.
+func (n *notifier) receive(msg query.Notification) {
+	n.child.Add(int64(msg.Delay() / time.Millisecond))
+
+	key := query.Notification{ID: msg.ID, Version: msg.Version}
+	if _, ok := n.received[key]; ok {
+		n.duplicates++
+		return
+	}
+	n.received[key] = struct{}{}
+}
+
+// counted wraps f to count the successful writes.
+func (n *notifier) counted(f plan.DoFunc) plan.DoFunc {
+	return func(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+		ok := f(data, id, rnd)
+		if ok {
+			atomic.AddUint64(&n.written, 1)
+		}
+		return ok
+	}
+}
+
+// verify waits for outstanding notifications and stops the subscriber,
+// returning the number of writes, notifications received, missed and
+// duplicated, and the notification latency.
+func (n *notifier) verify() ([][]string, []plan.Result, error) {
+	time.Sleep(n.grace)
+	err := n.stop()
+
+	go n.child.Done()
+	n.latency.Merge()
+
+	written := atomic.LoadUint64(&n.written)
+	received := uint64(len(n.received))
+
+	var missed uint64
+	if written > received {
+		missed = written - received
+	}
+
+	rows := [][]string{
+		{"NotifyWrites:", strconv.FormatUint(written, 10)},
+		{"NotifyReceived:", strconv.FormatUint(received, 10)},
+		{"NotifyMissed:", strconv.FormatUint(missed, 10)},
+		{"NotifyDuplicates:", strconv.FormatUint(n.duplicates, 10)},
+	}
+
+	return rows, []plan.Result{{Name: "notify", Histogram: n.latency}}, err
+}
@@ -2,6 +2,7 @@ package plan
 
 import (
 	"math/rand"
+	"time"
 
 	"github.com/domodwyer/dstats"
 	"github.com/domodwyer/mpjbt/idgen"
@@ -13,13 +14,66 @@ import (
 // If a DoFunc returns false, it's latency measurement is abandoned and it's
 // call does not count towards the operation limit. Implementations of DoFunc
 // should return false when an error occurs.
-type DoFunc func(data *record.Person, rid idgen.Generator, rnd *rand.Rand) bool
+type DoFunc func(data record.Record, rid idgen.Generator, rnd *rand.Rand) bool
 
-// operation combines a DoFunc and a collection of statistics.
+// CountFunc defines a database operation that also returns a count, such as
+// the number of rows read.
+//
+// The count is recorded in a histogram alongside the operation latency. The
+// same rules for the return value apply as for DoFunc.
+type CountFunc func(data record.Record, rid idgen.Generator, rnd *rand.Rand) (uint64, bool)
+
+// PageFunc defines a database operation fetching a single page of records.
+//
+// cursor identifies the position following the previous page (0 for the first
+// page), and next is passed as the cursor when fetching the following page. n
+// is the number of records read - a page with no records ends the scan. The
+// same rules for ok apply as for the return value of DoFunc.
+type PageFunc func(data record.Record, cursor uint64, rnd *rand.Rand) (next, n uint64, ok bool)
+
+// CheckFunc is called by a worker after each operation call, outside of the
+// measured latency, with the record passed to the call.
+type CheckFunc func(data record.Record)
+
+// operation combines a CountFunc and a collection of statistics.
 type operation struct {
 	counter   *dstats.DurationObserver
 	histogram *dstats.Histogram // not to be accessed concurrently
 
+	// counts is nil if the operation was added with Add.
+	counts *dstats.Histogram
+	unit   string
+
 	name   string
-	doFunc DoFunc
+	doFunc CountFunc
+
+	// failed counts the calls returning false - safe for concurrent access.
+	failed *uint64
+
+	// pageFunc is non-nil for operations added with AddPaged, in which case
+	// doFunc is nil and pageHistogram holds the latency of each page.
+	pageFunc      PageFunc
+	pages         uint64
+	pageHistogram *dstats.Histogram
+}
+
+// scan fetches up to op.pages pages with op.pageFunc, recording the latency of
+// each page in h and returning the total number of records read.
+func (op *operation) scan(data record.Record, rnd *rand.Rand, h *dstats.HistogramChild) (uint64, bool) {
+	var cursor, total uint64
+	for i := uint64(0); i < op.pages; i++ {
+		start := time.Now()
+		next, n, ok := op.pageFunc(data, cursor, rnd)
+		if !ok {
+			return total, false
+		}
+		h.Add(int64(time.Since(start) / time.Millisecond))
+
+		if n == 0 {
+			break
+		}
+		total += n
+		cursor = next
+	}
+	return total, true
 }
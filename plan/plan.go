@@ -28,9 +28,11 @@ var ErrStopped = errors.New("plan stopped")
 // A Plan stops the workers when the configured maximum number of operations is
 // reached, or Stop is called.
 type Plan struct {
-	id          idgen.GeneratorSource
-	ops         []operation
-	paddingSize uint64
+	id      idgen.GeneratorSource
+	records record.Source
+	ops     []operation
+	check   CheckFunc
+	onStart []func()
 
 	// Operation limits
 	opsMax   uint64
@@ -45,9 +47,20 @@ type Plan struct {
 
 // Result provides the name of an operation run as part of a Plan, and the
 // associated latency histogram for all it's calls.
+//
+// For operations added with AddCounted, Counts is the histogram of the counts
+// returned by each call, measured in Unit, with counts above maxCount recorded
+// as maxCount. Otherwise Counts is nil.
+//
+// Failed is the number of calls that returned false, and were not measured.
 type Result struct {
 	Name      string
 	Histogram *dstats.Histogram
+
+	Unit   string
+	Counts *dstats.Histogram
+
+	Failed uint64
 }
 
 // Run starts workers number of concurrent workers, and writes a description
@@ -66,6 +79,10 @@ func (p *Plan) Run(workers uint64, statusW io.Writer) []Result {
 		return nil
 	}
 
+	for _, f := range p.onStart {
+		f()
+	}
+
 	// Print out status updates
 	go p.statusTicker(statusW)
 
@@ -78,12 +95,27 @@ func (p *Plan) Run(workers uint64, statusW io.Writer) []Result {
 	wg.Wait()
 
 	// Collect results and return
-	var results = make([]Result, len(p.ops))
-	for i, op := range p.ops {
+	var results = make([]Result, 0, len(p.ops))
+	for _, op := range p.ops {
 		op.histogram.Merge()
-		results[i] = Result{
+		if op.counts != nil {
+			op.counts.Merge()
+		}
+
+		results = append(results, Result{
 			Name:      op.name,
 			Histogram: op.histogram,
+			Unit:      op.unit,
+			Counts:    op.counts,
+			Failed:    atomic.LoadUint64(op.failed),
+		})
+
+		if op.pageHistogram != nil {
+			op.pageHistogram.Merge()
+			results = append(results, Result{
+				Name:      op.name + "-page",
+				Histogram: op.pageHistogram,
+			})
 		}
 	}
 	return results
@@ -105,21 +137,105 @@ func (p *Plan) Stop() {
 //
 // Each worker will run all operations in the sequence provided to Add.
 func (p *Plan) Add(name string, f DoFunc) {
+	p.add(name, "", func(data record.Record, rid idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		return 0, f(data, rid, rnd)
+	})
+}
+
+// AddCounted pushes a new operation into the Plan run list, recording the count
+// returned by each call of f in a histogram alongside the latency.
+//
+// unit describes the count, such as "rows" or "bytes".
+func (p *Plan) AddCounted(name, unit string, f CountFunc) {
+	p.add(name, unit, f)
+}
+
+// AddPaged pushes a new operation into the Plan run list that scans through up
+// to pages pages of records using f, starting from the first page each call.
+//
+// The latency of the whole scan is recorded under name, along with the total
+// number of rows read, and the latency of each page under name with a "-page"
+// suffix.
+func (p *Plan) AddPaged(name string, pages uint64, f PageFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	op := newOperation(name, "rows", nil)
+	op.pageFunc = f
+	op.pages = pages
+	op.pageHistogram = NewLatencyHistogram()
+
+	p.ops = append(p.ops, op)
+}
+
+// OnStart adds f to the funcs called by Run before starting the workers.
+func (p *Plan) OnStart(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onStart = append(p.onStart, f)
+}
+
+// SetCheck sets f to be called by each worker after every operation call,
+// outside of the measured latency.
+func (p *Plan) SetCheck(f CheckFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.check = f
+}
+
+func (p *Plan) add(name, unit string, f CountFunc) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	p.ops = append(p.ops, newOperation(name, unit, f))
+}
+
+// newOperation returns an operation calling f, with a counts histogram if unit
+// is not empty.
+func newOperation(name, unit string, f CountFunc) operation {
 	op := operation{
-		name:    name,
-		doFunc:  f,
-		counter: &dstats.DurationObserver{},
-		histogram: dstats.NewHistogram(dstats.HistogramOptions{
-			NumBuckets:     100,
+		name:      name,
+		doFunc:    f,
+		counter:   &dstats.DurationObserver{},
+		histogram: NewLatencyHistogram(),
+		failed:    new(uint64),
+	}
+
+	if unit != "" {
+		op.unit = unit
+		op.counts = dstats.NewHistogram(dstats.HistogramOptions{
+			NumBuckets:     250,
 			GrowthFactor:   0.1,
 			BaseBucketSize: float64(1),
-		}),
+		})
 	}
 
-	p.ops = append(p.ops, op)
+	return op
+}
+
+// maxCount is the largest value recorded in a counts histogram - it's square
+// must fit in the int64 sum of squares, and it is within the range of the
+// histogram buckets.
+const maxCount = 3037000499 // floor(sqrt(math.MaxInt64))
+
+// clampCount returns n as a value for a counts histogram, clamped to maxCount.
+func clampCount(n uint64) int64 {
+	if n > maxCount {
+		return maxCount
+	}
+	return int64(n)
+}
+
+// NewLatencyHistogram returns a histogram for recording operation latency in
+// milliseconds, for latency measured outside of a Plan.
+func NewLatencyHistogram() *dstats.Histogram {
+	return dstats.NewHistogram(dstats.HistogramOptions{
+		NumBuckets:     100,
+		GrowthFactor:   0.1,
+		BaseBucketSize: float64(1),
+	})
 }
 
 // worker performs all the Plan operations in sequence until either the maximum
@@ -137,10 +253,18 @@ func (p *Plan) worker(wg *sync.WaitGroup) {
 	// Build a slice of our child histograms
 	counters := map[string]*dstats.DurationObserver{}
 	histograms := map[string]*dstats.HistogramChild{}
+	counts := map[string]*dstats.HistogramChild{}
+	pages := map[string]*dstats.HistogramChild{}
 	for _, op := range p.ops {
 		if _, exists := histograms[op.name]; !exists {
 			counters[op.name] = op.counter
 			histograms[op.name] = op.histogram.Split()
+			if op.counts != nil {
+				counts[op.name] = op.counts.Split()
+			}
+			if op.pageHistogram != nil {
+				pages[op.name] = op.pageHistogram.Split()
+			}
 		}
 	}
 
@@ -152,6 +276,12 @@ func (p *Plan) worker(wg *sync.WaitGroup) {
 				// called to prevent a deadlock - Done() is idempotent so this
 				// is fine.
 				histograms[op.name].Done()
+				if c, ok := counts[op.name]; ok {
+					c.Done()
+				}
+				if c, ok := pages[op.name]; ok {
+					c.Done()
+				}
 			}
 		}()
 	}()
@@ -159,7 +289,7 @@ func (p *Plan) worker(wg *sync.WaitGroup) {
 	// Calls to rand.Rand methods lock an underlying mutex, so each worker gets
 	// it's own instance.
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	record := &record.Person{Padding: make([]byte, p.paddingSize)}
+	record := p.records.New()
 	record.Randomise(rnd)
 
 	// Get a ID Generator safe for concurrent access
@@ -173,20 +303,37 @@ func (p *Plan) worker(wg *sync.WaitGroup) {
 		}
 
 		for _, op := range p.ops {
+			var n uint64
+			var measure bool
+
 			start := time.Now()
-			measure := op.doFunc(record, id, rnd)
+			if op.pageFunc != nil {
+				n, measure = op.scan(record, rnd, pages[op.name])
+			} else {
+				n, measure = op.doFunc(record, id, rnd)
+			}
 			delta := time.Since(start)
 
+			if p.check != nil {
+				p.check(record)
+			}
+
 			if !measure {
 				// If the DoFunc returns false, an error occurred and this
 				// measurement should be dropped.
 				//
 				// This does not count towards the operation count.
+				atomic.AddUint64(op.failed, 1)
 				continue
 			}
 
 			// Record in the histogram as milliseconds
 			histograms[op.name].Add(int64(delta / time.Millisecond))
+			if c, ok := counts[op.name]; ok {
+				if err := c.Add(clampCount(n)); err != nil {
+					log.Printf("%s: %v", op.name, err)
+				}
+			}
 
 			// Record in the operation counter - safe for concurrent access
 			counters[op.name].Observe(delta, 1)
@@ -255,14 +402,12 @@ func (p *Plan) SetIDGenerator(id idgen.GeneratorSource) {
 }
 
 // New returns an empty Plan, configured to run opsMax number of operations,
-// with paddingSize amount of randomised binary record padding.
-func New(opsMax uint64, paddingSize uint64) *Plan {
-	// TODO: move paddingSize into a record provider and keep it out of the
-	// plan.
+// with each worker using a record from records.
+func New(opsMax uint64, records record.Source) *Plan {
 	return &Plan{
-		id:          &idgen.MonotonicSource{},
-		stop:        make(chan struct{}),
-		paddingSize: paddingSize,
-		opsMax:      opsMax,
+		id:      &idgen.MonotonicSource{},
+		records: records,
+		stop:    make(chan struct{}),
+		opsMax:  opsMax,
 	}
 }
@@ -2,9 +2,13 @@ package plan
 
 import (
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/domodwyer/mpjbt/idgen"
 	"github.com/domodwyer/mpjbt/record"
 )
 
@@ -12,11 +16,11 @@ func TestPlan_Run(t *testing.T) {
 	const numCalls = 10000
 	const concurrency = 100
 
-	p := New(0)
+	p := New(0, &record.PersonSource{})
 
 	var seen uint64
-	p.Add("counter", func(data *record.Person) bool {
-		if data.ID > numCalls {
+	p.Add("counter", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
+		if rid.GetNew() > numCalls {
 			p.Stop()
 			return false
 		}
@@ -24,7 +28,7 @@ func TestPlan_Run(t *testing.T) {
 		return true
 	})
 
-	results := p.Run(numCalls+1, concurrency, ioutil.Discard)
+	results := p.Run(concurrency, ioutil.Discard)
 
 	if seen != numCalls {
 		t.Errorf("called %d times, want %d", seen, numCalls)
@@ -38,26 +42,31 @@ func TestPlan_Run(t *testing.T) {
 	if c := results[0].Histogram.Count; c != numCalls {
 		t.Errorf("histogram saw %d, want %d", c, numCalls)
 	}
+
+	if results[0].Counts != nil {
+		t.Errorf("got counts histogram for uncounted operation")
+	}
 }
 
 func TestPlan_DoesNotCallNext(t *testing.T) {
 	const numCalls = 1000
 	const concurrency = 10
 
-	p := New(0)
+	p := New(0, &record.PersonSource{})
 
-	p.Add("step1", func(data *record.Person) bool {
-		if data.ID > numCalls {
+	var step2 uint64
+	p.Add("step1", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
+		if rid.GetNew() > numCalls {
 			p.Stop()
 		}
 		return false
 	})
-	p.Add("step2", func(data *record.Person) bool {
-		panic("unexpected call to step2")
+	p.Add("step2", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
+		atomic.AddUint64(&step2, 1)
 		return true
 	})
 
-	results := p.Run(numCalls+1, concurrency, ioutil.Discard)
+	results := p.Run(concurrency, ioutil.Discard)
 
 	if len(results) != 2 {
 		t.Errorf("got %d results, want 2", len(results))
@@ -67,21 +76,137 @@ func TestPlan_DoesNotCallNext(t *testing.T) {
 	if c := results[0].Histogram.Count; c != 0 {
 		t.Errorf("histogram saw %d, want %d", c, 0)
 	}
+
+	if results[0].Failed < numCalls {
+		t.Errorf("got %d failed calls, want at least %d", results[0].Failed, numCalls)
+	}
+	if results[1].Failed != 0 {
+		t.Errorf("got %d failed calls for step2, want 0", results[1].Failed)
+	}
+
+	// A failed call does not stop the rest of the sequence
+	if c, n := results[1].Histogram.Count, atomic.LoadUint64(&step2); uint64(c) != n || c == 0 {
+		t.Errorf("step2 histogram saw %d, called %d times", c, n)
+	}
+}
+
+func TestPlan_AddCounted(t *testing.T) {
+	const numCalls = 1000
+	const concurrency = 10
+
+	p := New(numCalls, &record.PersonSource{})
+	p.AddCounted("rows", "rows", func(data record.Record, rid idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		return 42, true
+	})
+
+	results := p.Run(concurrency, ioutil.Discard)
+
+	counts := results[0].Counts
+	if counts == nil {
+		t.Fatal("no counts histogram")
+	}
+
+	if results[0].Unit != "rows" {
+		t.Errorf("got unit %q, want %q", results[0].Unit, "rows")
+	}
+
+	if counts.Count != results[0].Histogram.Count {
+		t.Errorf("counts saw %d, latency saw %d", counts.Count, results[0].Histogram.Count)
+	}
+
+	if counts.Min != 42 || counts.Max != 42 {
+		t.Errorf("got min %d max %d, want 42", counts.Min, counts.Max)
+	}
+}
+
+func TestPlan_AddCountedClamped(t *testing.T) {
+	const numCalls = 100
+
+	p := New(numCalls, &record.PersonSource{})
+	p.AddCounted("bytes", "bytes", func(data record.Record, rid idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		return math.MaxUint64, true
+	})
+
+	results := p.Run(1, ioutil.Discard)
+
+	counts := results[0].Counts
+	if counts.Count != results[0].Histogram.Count {
+		t.Errorf("counts saw %d, latency saw %d", counts.Count, results[0].Histogram.Count)
+	}
+	if counts.Max != maxCount {
+		t.Errorf("got max %d, want %d", counts.Max, int64(maxCount))
+	}
+	if counts.SumOfSquares <= 0 {
+		t.Errorf("sum of squares overflowed: %d", counts.SumOfSquares)
+	}
+}
+
+func TestPlan_OnStart(t *testing.T) {
+	p := New(1, &record.PersonSource{})
+
+	var started bool
+	p.OnStart(func() { started = true })
+	p.Add("step1", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
+		if !started {
+			t.Error("operation called before the OnStart funcs")
+		}
+		return true
+	})
+
+	if started {
+		t.Fatal("OnStart func called before Run")
+	}
+	p.Run(1, ioutil.Discard)
+	if !started {
+		t.Error("OnStart func not called by Run")
+	}
+}
+
+func TestPlan_SetCheck(t *testing.T) {
+	const numCalls = 20
+	const concurrency = 2
+
+	p := New(numCalls, &record.PersonSource{})
+
+	var calls, checks uint64
+	p.Add("step1", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
+		atomic.AddUint64(&calls, 1)
+		data.SetID(rid.GetNew())
+		return true
+	})
+	p.SetCheck(func(data record.Record) {
+		if data.GetID() == 0 {
+			t.Error("check not passed the record of the call")
+		}
+		atomic.AddUint64(&checks, 1)
+
+		// Not included in the measured latency
+		time.Sleep(20 * time.Millisecond)
+	})
+
+	results := p.Run(concurrency, ioutil.Discard)
+
+	if c, n := atomic.LoadUint64(&checks), atomic.LoadUint64(&calls); c != n {
+		t.Errorf("checked %d times, called %d times", c, n)
+	}
+	if max := results[0].Histogram.Max; max >= 20 {
+		t.Errorf("got max latency %dms, want the check excluded", max)
+	}
 }
 
 func TestPlan_StatusTicker(t *testing.T) {
 	const concurrency = 1
 
-	p := New(0)
-	p.Add("step1", func(data *record.Person) bool {
+	p := New(0, &record.PersonSource{})
+	p.Add("step1", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
 		return true
 	})
-	p.Add("step2", func(data *record.Person) bool {
+	p.Add("step2", func(data record.Record, rid idgen.Generator, _ *rand.Rand) bool {
 		p.Stop()
 		return true
 	})
 
-	p.Run(1, concurrency, ioutil.Discard)
+	p.Run(concurrency, ioutil.Discard)
 
 	want := "step1 1op/s avg.0ms\tstep2 1op/s avg.0ms"
 	got := p.buildLine()
@@ -90,3 +215,42 @@ func TestPlan_StatusTicker(t *testing.T) {
 		t.Errorf("got '%v', want '%v'", got, want)
 	}
 }
+
+func TestPlan_AddPaged(t *testing.T) {
+	const numCalls = 100
+	const concurrency = 10
+	const pages = 5
+
+	p := New(numCalls, &record.PersonSource{})
+
+	p.AddPaged("scan", pages, func(data record.Record, cursor uint64, _ *rand.Rand) (uint64, uint64, bool) {
+		// Return 10 records per page, running out before the last page.
+		if cursor >= 40 {
+			return cursor, 0, true
+		}
+		return cursor + 10, 10, true
+	})
+
+	results := p.Run(concurrency, ioutil.Discard)
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	scan, page := results[0], results[1]
+	if scan.Name != "scan" || page.Name != "scan-page" {
+		t.Errorf("got results %q and %q", scan.Name, page.Name)
+	}
+
+	// Every scan reads all 5 pages, the last one empty
+	if got, want := page.Histogram.Count, scan.Histogram.Count*pages; got != want {
+		t.Errorf("page histogram saw %d, want %d", got, want)
+	}
+
+	if scan.Counts.Count != scan.Histogram.Count {
+		t.Errorf("counts saw %d, latency saw %d", scan.Counts.Count, scan.Histogram.Count)
+	}
+	if scan.Counts.Min != 40 || scan.Counts.Max != 40 {
+		t.Errorf("got min %d max %d rows, want 40", scan.Counts.Min, scan.Counts.Max)
+	}
+}
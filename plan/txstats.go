@@ -0,0 +1,59 @@
+package plan
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TxStats counts the outcome of the transactions performed by an operation,
+// for operations that measure more than their overall latency.
+//
+// TxStats is safe for concurrent use.
+type TxStats struct {
+	commits     uint64
+	retries     uint64
+	aborts      uint64
+	commitNanos uint64
+}
+
+// Commit records a successful transaction taking d, measured from the start of
+// the transaction to the end of the commit.
+func (s *TxStats) Commit(d time.Duration) {
+	atomic.AddUint64(&s.commits, 1)
+	atomic.AddUint64(&s.commitNanos, uint64(d))
+}
+
+// Retry records a transaction that failed due to a conflict (such as a
+// serialization failure) and was retried.
+func (s *TxStats) Retry() {
+	atomic.AddUint64(&s.retries, 1)
+}
+
+// Abort records a transaction that was abandoned.
+func (s *TxStats) Abort() {
+	atomic.AddUint64(&s.aborts, 1)
+}
+
+// Rows returns the statistics as name/value pairs, or nil if no transactions
+// were recorded.
+func (s *TxStats) Rows() [][]string {
+	commits := atomic.LoadUint64(&s.commits)
+	retries := atomic.LoadUint64(&s.retries)
+	aborts := atomic.LoadUint64(&s.aborts)
+	if commits+retries+aborts == 0 {
+		return nil
+	}
+
+	var avg time.Duration
+	if commits > 0 {
+		avg = time.Duration(atomic.LoadUint64(&s.commitNanos) / commits)
+	}
+
+	return [][]string{
+		{"TxCommits:", strconv.FormatUint(commits, 10)},
+		{"TxRetries:", strconv.FormatUint(retries, 10)},
+		{"TxAborts:", strconv.FormatUint(aborts, 10)},
+		{"TxAvgLatency:", avg.String()},
+	}
+}
@@ -0,0 +1,35 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTxStats(t *testing.T) {
+	var s TxStats
+	if rows := s.Rows(); rows != nil {
+		t.Errorf("got rows %v before any transactions", rows)
+	}
+
+	s.Commit(10 * time.Millisecond)
+	s.Commit(20 * time.Millisecond)
+	s.Retry()
+	s.Abort()
+
+	want := [][]string{
+		{"TxCommits:", "2"},
+		{"TxRetries:", "1"},
+		{"TxAborts:", "1"},
+		{"TxAvgLatency:", "15ms"},
+	}
+
+	rows := s.Rows()
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if rows[i][0] != want[i][0] || rows[i][1] != want[i][1] {
+			t.Errorf("got row %v, want %v", rows[i], want[i])
+		}
+	}
+}
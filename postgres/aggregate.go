@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// CountEnabled counts the records grouped by the enabled field, returning the
+// number of groups.
+func (p *FuncProvider) CountEnabled(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	out, err := p.EnabledCounts()
+	if err == nil {
+		err = query.VerifyEnabledCounts(out)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0, false
+	}
+
+	return uint64(len(out)), true
+}
+
+// EnabledCounts returns the number of records with each value of the enabled
+// field, ignoring records without it.
+func (p *FuncProvider) EnabledCounts() ([]query.EnabledCount, error) {
+	rows, err := p.DB.Query(
+		"SELECT (data->>'enabled')::boolean, count(*) FROM " + p.TableName +
+			" WHERE data ? 'enabled' GROUP BY 1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []query.EnabledCount
+	for rows.Next() {
+		var r query.EnabledCount
+		if err := rows.Scan(&r.Enabled, &r.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+// AvgBalanceByAge calculates the average balance of the records in each
+// query.AgeBucketWidth wide age bucket, returning the number of buckets.
+func (p *FuncProvider) AvgBalanceByAge(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	out, err := p.AgeBuckets()
+	if err == nil {
+		err = query.VerifyAgeBuckets(out)
+	}
+	if err != nil {
+		log.Println(err)
+		return 0, false
+	}
+
+	return uint64(len(out)), true
+}
+
+// AgeBuckets returns the average balance of the records in each
+// query.AgeBucketWidth wide age bucket ordered by age, ignoring records without
+// an age.
+func (p *FuncProvider) AgeBuckets() ([]query.AgeBucket, error) {
+	width := strconv.Itoa(query.AgeBucketWidth)
+	rows, err := p.DB.Query(
+		"SELECT ((data->>'age')::bigint / " + width + ") * " + width + " AS bucket, avg((data->>'balance')::float8) FROM " + p.TableName +
+			" WHERE data ? 'age' GROUP BY bucket ORDER BY bucket",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []query.AgeBucket
+	for rows.Next() {
+		var r query.AgeBucket
+		if err := rows.Scan(&r.Age, &r.AvgBalance); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+// TopCounters returns a CountFunc fetching the IDs and counters of the n
+// records with the highest counter (ties are broken by the highest ID),
+// returning the number of records read.
+func (p *FuncProvider) TopCounters(n uint64) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		out, err := p.CounterRanks(n)
+		if err == nil {
+			err = query.VerifyTopCounters(out, n)
+		}
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return uint64(len(out)), true
+	}
+}
+
+// CounterRanks returns the IDs and counters of the n records with the highest
+// counter (ties are broken by the highest ID), ignoring records without a
+// counter.
+func (p *FuncProvider) CounterRanks(n uint64) ([]query.CounterRank, error) {
+	rows, err := p.DB.Query(
+		"SELECT (data->>'id')::bigint, (data->>'counter')::bigint FROM " + p.TableName +
+			" WHERE data ? 'counter'" +
+			" ORDER BY (data->'counter') DESC, (data->'id') DESC LIMIT " + strconv.FormatUint(n, 10),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []query.CounterRank
+	for rows.Next() {
+		var r query.CounterRank
+		if err := rows.Scan(&r.ID, &r.Counter); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// CountRecords returns the number of records in the table.
+func (p *FuncProvider) CountRecords() (uint64, error) {
+	var n uint64
+	err := p.DB.QueryRow("SELECT count(*) FROM " + p.TableName).Scan(&n)
+	return n, err
+}
+
+// ScanRecords calls f for every record in the table in ID order, with the
+// record decoded into a copy of into.Empty() and any decoding error, including
+// unknown or missing fields (see record.CheckFields).
+func (p *FuncProvider) ScanRecords(into record.Record, f func(record.Record, error)) error {
+	rows, err := p.DB.Query("SELECT data FROM " + p.TableName + " ORDER BY data->'id'")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return err
+		}
+
+		rec := into.Empty()
+		f(rec, decodeRecord(raw, rec))
+	}
+
+	return rows.Err()
+}
+
+// decodeRecord decodes the JSON encoded record raw into rec, checking it has
+// the fields of rec.
+func decodeRecord(raw []byte, rec record.Record) error {
+	if err := json.Unmarshal(raw, rec); err != nil {
+		return err
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	return record.CheckFields(rec, names)
+}
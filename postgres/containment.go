@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/lib/pq"
+)
+
+// ReadContains fetches all enabled records with a random tag using the @>
+// containment operator, which can be answered by a GIN index on the document
+// using either the jsonb_ops or jsonb_path_ops operator class.
+func (p *FuncProvider) ReadContains(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	filter, err := json.Marshal(map[string]interface{}{
+		"enabled": true,
+		"tags":    []string{record.RandomTag(data, rnd)},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data @> $1", string(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadTagExists fetches all records with a random tag using the ? existence
+// operator on the tags array.
+//
+// The ? operator is supported by the jsonb_ops GIN operator class, but not
+// jsonb_path_ops.
+func (p *FuncProvider) ReadTagExists(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data->'tags' ? $1", record.RandomTag(data, rnd)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadAnyTagExists fetches all records with either of two random tags using the
+// ?| existence operator on the tags array.
+//
+// The ?| operator is supported by the jsonb_ops GIN operator class, but not
+// jsonb_path_ops.
+func (p *FuncProvider) ReadAnyTagExists(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	tags := pq.Array([]string{record.RandomTag(data, rnd), record.RandomTag(data, rnd)})
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data->'tags' ?| $1", tags); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
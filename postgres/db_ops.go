@@ -11,6 +11,8 @@ import (
 	"strconv"
 
 	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
 	"github.com/domodwyer/mpjbt/record"
 	_ "github.com/lib/pq"
 )
@@ -24,13 +26,24 @@ import (
 type FuncProvider struct {
 	DB        *sql.DB
 	TableName string
+
+	// endpoint is the connection string, used to open dedicated connections
+	// such as a pq.Listener.
+	endpoint string
+
+	// tx counts the outcome of the transfer transactions.
+	tx plan.TxStats
+
+	// runStart holds the database wide statistics at the start of the run.
+	runStart dbCounters
 }
 
 // InsertRecord generates a new random record and inserts it with an ID provided
-// by id.GetNew as a JSON-encoded string.
-func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+// by id.GetNew as a JSON-encoded string, returning the size of the encoded
+// record in bytes.
+func (p *FuncProvider) InsertRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
 	data.Randomise(rnd)
-	data.ID = id.GetNew()
+	data.SetID(id.GetNew())
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -40,17 +53,17 @@ func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd
 	_, err = p.DB.Exec("INSERT INTO "+p.TableName+" (data) VALUES ($1)", string(jsonData))
 	if err != nil {
 		log.Println(err)
-		return false
+		return 0, false
 	}
 
-	return true
+	return uint64(len(jsonData)), true
 }
 
 // UpdateRecord attempts to update the record with ID returned by
 // id.GetExisting.
 //
 // The balance field is changed to a random value from rnd using jsonb_set.
-func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+func (p *FuncProvider) UpdateRecord(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
 	recordID := id.GetExisting()
 	_, err := p.DB.Exec(
 		"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{balance}', $1::jsonb, false) where data->'id'=$2;",
@@ -66,57 +79,121 @@ func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *r
 
 // ReadRecord attempts to fetch the record with an ID returned by
 // id.GetExisting.
-func (p *FuncProvider) ReadRecord(_ *record.Person, id idgen.Generator, _ *rand.Rand) bool {
+func (p *FuncProvider) ReadRecord(data record.Record, id idgen.Generator, _ *rand.Rand) bool {
 	recordID := id.GetExisting()
 
-	var rawData []byte
-	err := p.DB.QueryRow("SELECT data FROM "+p.TableName+" WHERE data->'id'=$1", recordID).Scan(&rawData)
+	found, err := p.FetchRecord(data.Empty(), recordID)
+	if err == nil && !found {
+		err = sql.ErrNoRows
+	}
 	if err != nil {
 		log.Println(recordID, err)
 		return false
 	}
 
-	var data = &record.Person{}
-	if err := json.Unmarshal(rawData, &data); err != nil {
-		log.Println(recordID, err)
-		return false
+	return true
+}
+
+// FetchRecord decodes the record with ID recordID into into, returning false if
+// it does not exist.
+func (p *FuncProvider) FetchRecord(into record.Record, recordID uint64) (bool, error) {
+	var rawData []byte
+	err := p.DB.QueryRow("SELECT data FROM "+p.TableName+" WHERE data->'id'=$1", recordID).Scan(&rawData)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
 
-	return true
+	return true, json.Unmarshal(rawData, into)
 }
 
-// ReadRange performs a range query on the age field.
+// ReadRange returns a CountFunc performing the range query described by q,
+// returning the number of records read.
 //
-// THe query attempts to fetch all records where age is greater than 45 and less
-// than 75.
-func (p *FuncProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
-	rows, err := p.DB.Query("SELECT data FROM " + p.TableName + " WHERE (data->'age') > '45' AND (data->'age') < '75'")
+// The bounds are formatted into the query as literals, rather than passed as
+// parameters, to allow the planner to match the query against partial indexes.
+func (p *FuncProvider) ReadRange(q *query.Range) plan.CountFunc {
+	field := "(data->'" + q.Field + "')"
+
+	var suffix string
+	switch q.Sort {
+	case query.Ascending:
+		suffix = " ORDER BY " + field + " ASC"
+	case query.Descending:
+		suffix = " ORDER BY " + field + " DESC"
+	}
+	if q.Limit != 0 {
+		suffix += " LIMIT " + strconv.FormatUint(q.Limit, 10)
+	}
+
+	return func(data record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		lo, hi := q.Bounds.Next(rnd)
+		n, err := p.readRecords(
+			data.Empty(),
+			"SELECT data FROM "+p.TableName+
+				" WHERE "+field+" > '"+strconv.FormatFloat(lo, 'f', -1, 64)+"'"+
+				" AND "+field+" < '"+strconv.FormatFloat(hi, 'f', -1, 64)+"'"+
+				suffix,
+		)
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return n, true
+	}
+}
+
+// SampleField returns the value of field from n random records.
+func (p *FuncProvider) SampleField(field string, n int) ([]float64, error) {
+	rows, err := p.DB.Query("SELECT (data->>'"+field+"')::float8 FROM "+p.TableName+" ORDER BY random() LIMIT $1", n)
 	if err != nil {
-		log.Println(err)
-		return false
+		return nil, err
 	}
 	defer rows.Close()
 
+	var out []float64
+	for rows.Next() {
+		var v float64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, rows.Err()
+}
+
+// readRecords runs stmt and decodes every returned record into into, returning
+// the number of records read.
+func (p *FuncProvider) readRecords(into record.Record, stmt string, args ...interface{}) (uint64, error) {
+	rows, err := p.DB.Query(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n uint64
 	var rawData []byte
-	var data = &record.Person{}
 	for rows.Next() {
 		if err := rows.Scan(&rawData); err != nil {
-			log.Println(err)
-			return false
+			return n, err
 		}
 
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			log.Println(err)
-			return false
+		if err := json.Unmarshal(rawData, into); err != nil {
+			return n, err
 		}
+		n++
 	}
 
-	return true
+	return n, rows.Err()
 }
 
 // ReadMostRecentRecord fetches the most recently inserted record by performing
 // a sort on the ID field, and limiting the results to a single record.
-func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+func (p *FuncProvider) ReadMostRecentRecord(data record.Record, _ idgen.Generator, _ *rand.Rand) bool {
 	var rawData []byte
 	err := p.DB.QueryRow("SELECT data FROM " + p.TableName + " ORDER BY data->'id' DESC LIMIT 1").Scan(&rawData)
 	if err != nil {
@@ -124,8 +201,7 @@ func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator,
 		return false
 	}
 
-	var data = &record.Person{}
-	if err := json.Unmarshal(rawData, &data); err != nil {
+	if err := json.Unmarshal(rawData, data.Empty()); err != nil {
 		log.Println(err)
 		return false
 	}
@@ -144,7 +220,22 @@ func (p *FuncProvider) GetMaxID() (uint64, error) {
 }
 
 // NewProvider returns an instance of FuncProvider.
+//
+// The following options are available in addition to the usual dial string
+// options provided by the pq driver:
+//
+//	synchronousCommit: off/local/remote_write/on/remote_apply
+//	isolation: read committed/repeatable read/serializable
+//	statementTimeout: <duration> (such as 5s, or milliseconds)
+//	applicationName: <name>
+//
+// The options are sent as run-time parameters when each connection is opened,
+// so they apply to every connection in the pool.
 func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
+	if err := setSessionOptions(endpoint); err != nil {
+		return nil, err
+	}
+
 	// Connect to postgres
 	db, err := sql.Open("postgres", endpoint.String())
 	if err != nil {
@@ -165,5 +256,6 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	return &FuncProvider{
 		DB:        db,
 		TableName: tableName,
+		endpoint:  endpoint.String(),
 	}, nil
 }
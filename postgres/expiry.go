@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// expiringTable returns the name of the table used by the expire workload.
+func (p *FuncProvider) expiringTable() string {
+	return p.TableName + "_expiring"
+}
+
+// InsertExpiring returns a CountFunc inserting a random record that expires
+// after ttl, returning the size of the encoded record in bytes.
+//
+// The record is nested under "record", with the expiry time in milliseconds
+// since the epoch stored in "expires".
+func (p *FuncProvider) InsertExpiring(ttl time.Duration) plan.CountFunc {
+	return func(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		data.Randomise(rnd)
+		data.SetID(id.GetNew())
+
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = p.DB.Exec(
+			"INSERT INTO "+p.expiringTable()+" (data) VALUES (jsonb_build_object('expires', $1::bigint, 'record', $2::jsonb))",
+			unixMillis(time.Now().Add(ttl)),
+			string(jsonData),
+		)
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return uint64(len(jsonData)), true
+	}
+}
+
+// PurgeExpired deletes the expired records, returning the number of records
+// deleted.
+func (p *FuncProvider) PurgeExpired() (uint64, error) {
+	res, err := p.DB.Exec(
+		"DELETE FROM "+p.expiringTable()+" WHERE (data->>'expires')::bigint < $1",
+		unixMillis(time.Now()),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return uint64(n), err
+}
+
+// ExpiringSize returns the number of records in the expiring table, and it's
+// total size in bytes including indexes and TOAST.
+//
+// The number of records is the live row estimate maintained by the statistics
+// collector rather than a count, so sampling does not scan the table.
+func (p *FuncProvider) ExpiringSize() (uint64, uint64, error) {
+	var rows, size uint64
+	err := p.DB.QueryRow(
+		"SELECT n_live_tup, pg_total_relation_size(relid) FROM pg_stat_user_tables WHERE relid = $1::regclass",
+		p.expiringTable(),
+	).Scan(&rows, &size)
+	return rows, size, err
+}
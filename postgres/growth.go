@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// PushAddress appends a random address to the addresses array of the record
+// with ID returned by id.GetExisting using the || concatenation operator,
+// increasing the document size.
+func (p *FuncProvider) PushAddress(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	addr, err := json.Marshal([]record.Address{record.RandomAddress(rnd)})
+	if err != nil {
+		panic(err)
+	}
+
+	recordID := id.GetExisting()
+	_, err = p.DB.Exec(
+		"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{addresses}', COALESCE(data->'addresses', '[]') || $1::jsonb) WHERE data->'id'=$2",
+		string(addr),
+		recordID,
+	)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
+
+// GrowPadding returns a DoFunc appending padding generated by gen, sized by
+// size, to the growth array of the record with ID returned by id.GetExisting.
+//
+// Growing records eventually exceed the TOAST threshold (approximately 2kb) and
+// are moved out of line.
+func (p *FuncProvider) GrowPadding(size record.Size, gen *record.PaddingGenerator) plan.DoFunc {
+	return func(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+		padding, err := json.Marshal([]record.Padding{gen.Generate(size.Next(rnd), rnd)})
+		if err != nil {
+			panic(err)
+		}
+
+		recordID := id.GetExisting()
+		_, err = p.DB.Exec(
+			"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{growth}', COALESCE(data->'growth', '[]') || $1::jsonb) WHERE data->'id'=$2",
+			string(padding),
+			recordID,
+		)
+		if err != nil {
+			log.Println(recordID, err)
+			return false
+		}
+
+		return true
+	}
+}
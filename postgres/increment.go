@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// IncrementCounter increments the counter field of the record with ID returned
+// by id.GetExisting.
+//
+// The read and write happen in a single UPDATE, so concurrent increments of
+// the same record wait on it's row lock rather than losing updates.
+func (p *FuncProvider) IncrementCounter(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	recordID := id.GetExisting()
+	res, err := p.DB.Exec(
+		"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{counter}', ((data->>'counter')::int+1)::text::jsonb) WHERE data->'id'=$1",
+		recordID,
+	)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	n, err := res.RowsAffected()
+	if err == nil && n != 1 {
+		log.Println(recordID, "record not found")
+		return false
+	}
+	return err == nil
+}
+
+// SumCounters returns the sum of the counter field of the records with an ID
+// less than or equal to maxID.
+func (p *FuncProvider) SumCounters(maxID uint64) (int64, error) {
+	var sum int64
+	err := p.DB.QueryRow(
+		"SELECT COALESCE(SUM((data->>'counter')::bigint), 0) FROM "+p.TableName+" WHERE data->'id' <= $1",
+		maxID,
+	).Scan(&sum)
+	return sum, err
+}
+
+// LockWaits returns the number of sessions in the database currently waiting
+// to acquire a lock, such as a row lock held by a concurrent increment.
+func (p *FuncProvider) LockWaits() (uint64, error) {
+	var n uint64
+	err := p.DB.QueryRow(
+		"SELECT count(*) FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND datname = current_database()",
+	).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// balanceWidth is the width of the balance range queried by ReadBalancePath.
+const balanceWidth = 0.01
+
+// ReadAddressPath fetches all records with an address number matching a random
+// value using the @? jsonpath operator, which can be answered by a GIN index on
+// the document.
+//
+// Requires Postgres 12+.
+func (p *FuncProvider) ReadAddressPath(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	path := fmt.Sprintf("$.addresses[*] ? (@.Number == %d)", rnd.Intn(256))
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data @? $1::jsonpath", path); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadAddressPathFunc performs the same query as ReadAddressPath using the
+// jsonb_path_exists function with the address number passed as a jsonpath
+// variable.
+//
+// Unlike the @? operator, jsonb_path_exists cannot use an index.
+//
+// Requires Postgres 12+.
+func (p *FuncProvider) ReadAddressPathFunc(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	_, err := p.readRecords(
+		data.Empty(),
+		"SELECT data FROM "+p.TableName+" WHERE jsonb_path_exists(data, '$.addresses[*] ? (@.Number == $n)', jsonb_build_object('n', $1::int))",
+		rnd.Intn(256),
+	)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadBalancePath fetches all records with a balance in a random range of
+// balanceWidth using the @@ jsonpath predicate operator.
+//
+// Requires Postgres 12+.
+func (p *FuncProvider) ReadBalancePath(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	lo := rnd.NormFloat64()
+	path := "$.balance >= " + strconv.FormatFloat(lo, 'f', -1, 64) +
+		" && $.balance < " + strconv.FormatFloat(lo+balanceWidth, 'f', -1, 64)
+
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data @@ $1::jsonpath", path); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadAddressLinesPath projects the first address line of each address in the
+// record with an ID returned by id.GetExisting using jsonb_path_query_array.
+//
+// Requires Postgres 12+.
+func (p *FuncProvider) ReadAddressLinesPath(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	recordID := id.GetExisting()
+
+	var rawData []byte
+	err := p.DB.QueryRow(
+		"SELECT jsonb_path_query_array(data, '$.addresses[*].Line1') FROM "+p.TableName+" WHERE data->'id'=$1",
+		recordID,
+	).Scan(&rawData)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	var lines []string
+	if err := json.Unmarshal(rawData, &lines); err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
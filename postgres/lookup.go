@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ReadByPhone fetches the record with the phone number of the ID returned by
+// id.GetExisting, returning the number of records read.
+//
+// The query can be answered by the schema.PhoneIndex expression index.
+func (p *FuncProvider) ReadByPhone(data record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	phone := record.PhoneNumber(id.GetExisting())
+
+	n, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data->>'phone_number' = $1", phone)
+	if err != nil {
+		log.Println(phone, err)
+		return 0, false
+	}
+
+	return n, true
+}
+
+// ReadPhoneCovered checks a record with the phone number of the ID returned by
+// id.GetExisting exists, reading only the generated phone_number column,
+// returning the number of records found.
+//
+// Requires the schema.PhoneColumnIndex, which allows an index-only scan.
+func (p *FuncProvider) ReadPhoneCovered(_ record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	phone := record.PhoneNumber(id.GetExisting())
+
+	rows, err := p.DB.Query("SELECT phone_number FROM "+p.TableName+" WHERE phone_number = $1", phone)
+	if err != nil {
+		log.Println(phone, err)
+		return 0, false
+	}
+	defer rows.Close()
+
+	var n uint64
+	var got string
+	for rows.Next() {
+		if err := rows.Scan(&got); err != nil {
+			log.Println(phone, err)
+			return n, false
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(phone, err)
+		return n, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ReadAddressLine fetches all records with an address on a random street using
+// the @> containment operator on the addresses array, which can be answered by
+// a GIN index on data->'addresses'.
+func (p *FuncProvider) ReadAddressLine(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	filter, err := json.Marshal([]map[string]interface{}{
+		{"Line1": record.RandomAddress(rnd).Line1},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data->'addresses' @> $1", string(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadAddressMatch fetches all records with a single address matching both a
+// random number and street using the @> containment operator on the addresses
+// array, equivalent to a MongoDB $elemMatch query.
+func (p *FuncProvider) ReadAddressMatch(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	addr := record.RandomAddress(rnd)
+	filter, err := json.Marshal([]map[string]interface{}{
+		{"Number": addr.Number, "Line1": addr.Line1},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := p.readRecords(data.Empty(), "SELECT data FROM "+p.TableName+" WHERE data->'addresses' @> $1", string(filter)); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+// ReadAddressElements performs the same query as ReadAddressMatch by expanding
+// the addresses array with jsonb_array_elements.
+//
+// Unlike the @> operator, the subquery cannot use an index.
+func (p *FuncProvider) ReadAddressElements(data record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+	addr := record.RandomAddress(rnd)
+	_, err := p.readRecords(
+		data.Empty(),
+		"SELECT data FROM "+p.TableName+" WHERE EXISTS ("+
+			"SELECT 1 FROM jsonb_array_elements(data->'addresses') a "+
+			"WHERE (a->>'Number')::int = $1 AND a->>'Line1' = $2)",
+		addr.Number,
+		addr.Line1,
+	)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/lib/pq"
+)
+
+// notifyTable returns the name of the table used by the notify workload, which
+// is also the name of the notification channel and trigger function.
+func (p *FuncProvider) notifyTable() string {
+	return p.TableName + "_notify"
+}
+
+// InsertNotification inserts a notification with an ID provided by id.GetNew.
+//
+// A trigger created by Setup sends the document with pg_notify.
+func (p *FuncProvider) InsertNotification(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	n := query.NewNotification(id.GetNew(), 1)
+	return p.writeNotification("INSERT INTO "+p.notifyTable()+" (data) VALUES ($1)", n)
+}
+
+// UpdateNotification replaces the notification with ID returned by
+// id.GetExisting with it's second version.
+func (p *FuncProvider) UpdateNotification(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	n := query.NewNotification(id.GetExisting(), 2)
+	return p.writeNotification("UPDATE "+p.notifyTable()+" SET data = $1 WHERE data->'id' = $2", n, n.ID)
+}
+
+// writeNotification executes stmt with n encoded as JSON as the first
+// argument, returning false unless exactly one row was written.
+func (p *FuncProvider) writeNotification(stmt string, n query.Notification, args ...interface{}) bool {
+	data, err := json.Marshal(n)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := p.DB.Exec(stmt, append([]interface{}{string(data)}, args...)...)
+	if err != nil {
+		log.Println(n.ID, err)
+		return false
+	}
+
+	rows, err := res.RowsAffected()
+	if err == nil && rows != 1 {
+		log.Println(n.ID, "notification not found")
+		return false
+	}
+	return err == nil
+}
+
+// Subscribe listens for notifications on the notify table channel with a
+// pq.Listener, calling f from a single goroutine for each notification
+// received until the returned stop func is called.
+func (p *FuncProvider) Subscribe(f func(query.Notification)) (func() error, error) {
+	listener := pq.NewListener(p.endpoint, time.Second, 10*time.Second, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("listener: %v", err)
+		}
+	})
+	if err := listener.Listen(p.notifyTable()); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case msg := <-listener.Notify:
+				// A nil notification is sent after reconnecting, when
+				// notifications may have been missed.
+				if msg == nil {
+					continue
+				}
+
+				var n query.Notification
+				if err := json.Unmarshal([]byte(msg.Extra), &n); err != nil {
+					log.Printf("listener: %v", err)
+					continue
+				}
+				f(n)
+
+			case <-time.After(time.Minute):
+				go listener.Ping()
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		wg.Wait()
+		return listener.Close()
+	}, nil
+}
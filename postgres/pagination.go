@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ReadPageOffset returns a PageFunc fetching pages of size records ordered by
+// ID, skipping the records in previous pages with OFFSET.
+//
+// The cursor is the number of records already read, so the database must read
+// and discard every record before the page.
+func (p *FuncProvider) ReadPageOffset(size uint64) plan.PageFunc {
+	stmt := "SELECT data FROM " + p.TableName + " ORDER BY data->'id' LIMIT " + strconv.FormatUint(size, 10) + " OFFSET $1"
+
+	return func(data record.Record, cursor uint64, _ *rand.Rand) (uint64, uint64, bool) {
+		n, err := p.readRecords(data.Empty(), stmt, cursor)
+		if err != nil {
+			log.Println(err)
+			return 0, 0, false
+		}
+
+		return cursor + n, n, true
+	}
+}
+
+// ReadPageKeyset returns a PageFunc fetching pages of size records ordered by
+// ID, starting after the last ID of the previous page.
+//
+// The cursor is the last ID read, allowing the ID index to seek directly to the
+// start of the page.
+func (p *FuncProvider) ReadPageKeyset(size uint64) plan.PageFunc {
+	stmt := "SELECT data FROM " + p.TableName + " WHERE data->'id' > $1 ORDER BY data->'id' LIMIT " + strconv.FormatUint(size, 10)
+
+	return func(data record.Record, cursor uint64, _ *rand.Rand) (uint64, uint64, bool) {
+		last := data.Empty()
+		n, err := p.readRecords(last, stmt, cursor)
+		if err != nil {
+			log.Println(err)
+			return 0, 0, false
+		}
+
+		return last.GetID(), n, true
+	}
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math/rand"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ReadFields fetches the name and balance of the record with an ID returned by
+// id.GetExisting as separate columns.
+//
+// Each field expression detoasts the document independently, so large records
+// are decompressed once per projected field. Missing fields are NULL, and left
+// empty as in MongoDB.
+func (p *FuncProvider) ReadFields(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	recordID := id.GetExisting()
+
+	var (
+		name    sql.NullString
+		balance sql.NullFloat64
+	)
+	err := p.DB.QueryRow(
+		"SELECT data->>'name', (data->>'balance')::float8 FROM "+p.TableName+" WHERE data->'id'=$1",
+		recordID,
+	).Scan(&name, &balance)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
+
+// ReadFieldsObject fetches the name and balance of the record with an ID
+// returned by id.GetExisting as a single JSON object built with
+// jsonb_build_object.
+func (p *FuncProvider) ReadFieldsObject(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	recordID := id.GetExisting()
+
+	var rawData []byte
+	err := p.DB.QueryRow(
+		"SELECT jsonb_build_object('name', data->'name', 'balance', data->'balance') FROM "+p.TableName+" WHERE data->'id'=$1",
+		recordID,
+	).Scan(&rawData)
+	if err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	var out query.Projection
+	if err := json.Unmarshal(rawData, &out); err != nil {
+		log.Println(recordID, err)
+		return false
+	}
+
+	return true
+}
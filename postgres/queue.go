@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// jobsTable returns the name of the table used by the queue workload.
+func (p *FuncProvider) jobsTable() string {
+	return p.TableName + "_jobs"
+}
+
+// EnqueueJob inserts a pending job with an ID returned by id.GetNew, recording
+// the time it was enqueued.
+func (p *FuncProvider) EnqueueJob(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	_, err := p.DB.Exec(
+		"INSERT INTO "+p.jobsTable()+" (data) VALUES (jsonb_build_object('id', $1::bigint, 'state', 'pending', 'enqueued', $2::bigint))",
+		id.GetNew(),
+		time.Now().UnixNano(),
+	)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+// ClaimJob claims the oldest pending job with token, incrementing the number of
+// times it has been claimed.
+//
+// The job is selected with FOR UPDATE SKIP LOCKED so concurrent consumers
+// claim different jobs without waiting on each other. If the queue is empty
+// ClaimJob returns query.ErrQueueEmpty.
+func (p *FuncProvider) ClaimJob(token uint64) (query.Job, error) {
+	job := query.Job{Token: token}
+
+	var enqueued int64
+	err := p.DB.QueryRow(`
+		UPDATE `+p.jobsTable()+` SET data = data || jsonb_build_object(
+			'state', 'claimed',
+			'claimed', $1::bigint,
+			'token', $2::bigint,
+			'claims', coalesce((data->>'claims')::bigint, 0) + 1
+		)
+		WHERE ctid = (
+			SELECT ctid FROM `+p.jobsTable()+`
+			WHERE data->>'state' = 'pending'
+			ORDER BY data->'enqueued'
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING (data->>'id')::bigint, (data->>'enqueued')::bigint`,
+		time.Now().UnixNano(),
+		token,
+	).Scan(&job.ID, &enqueued)
+	if err == sql.ErrNoRows {
+		return job, query.ErrQueueEmpty
+	}
+	if err != nil {
+		return job, err
+	}
+
+	job.Queued = time.Since(time.Unix(0, enqueued))
+	return job, nil
+}
+
+// CompleteJob marks job as done if it is still claimed with job.Token.
+func (p *FuncProvider) CompleteJob(job query.Job) error {
+	res, err := p.DB.Exec(
+		"UPDATE "+p.jobsTable()+" SET data = jsonb_set(data, '{state}', '\"done\"') WHERE data->'id' = $1 AND data->'token' = $2 AND data->>'state' = 'claimed'",
+		job.ID,
+		job.Token,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return errors.New("job not claimed with this token")
+	}
+	return nil
+}
+
+// QueueStats counts the jobs in each state, and the jobs claimed more than
+// once, as name/value pairs.
+func (p *FuncProvider) QueueStats() ([][]string, error) {
+	var pending, claimed, done, doubles int64
+	err := p.DB.QueryRow(`
+		SELECT
+			count(*) FILTER (WHERE data->>'state' = 'pending'),
+			count(*) FILTER (WHERE data->>'state' = 'claimed'),
+			count(*) FILTER (WHERE data->>'state' = 'done'),
+			count(*) FILTER (WHERE (data->>'claims')::bigint > 1)
+		FROM `+p.jobsTable(),
+	).Scan(&pending, &claimed, &done, &doubles)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]string{
+		{"JobsPending:", strconv.FormatInt(pending, 10)},
+		{"JobsClaimed:", strconv.FormatInt(claimed, 10)},
+		{"JobsDone:", strconv.FormatInt(done, 10)},
+		{"DoubleClaims:", strconv.FormatInt(doubles, 10)},
+	}, nil
+}
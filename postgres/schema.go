@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/domodwyer/mpjbt/schema"
+)
+
+// Setup creates the table and indexes described by opts.
+//
+// Records are stored as a single jsonb column named data, with a BTREE index on
+// data->'id'. A jobs table is created for the queue workload, with an index on
+// the job ID and a partial index on the pending jobs, and an events table for
+// the time-series workload, partitioned by event time if opts.PartitionInterval
+// is set. An expiring table with an index on the expiry time is created for the
+// expire workload, and a notify table with a trigger calling pg_notify for each
+// inserted or updated row for the notify workload. The dob index orders the
+// records by time, using the function named by timestampFunc.
+func (p *FuncProvider) Setup(opts schema.Options) error {
+	column := "data jsonb"
+	if opts.Compression != "" {
+		column += " COMPRESSION " + opts.Compression
+	}
+
+	table := "CREATE TABLE " + p.TableName + " (" + column + ")"
+	if opts.FillFactor != 0 {
+		table += fmt.Sprintf(" WITH (fillfactor=%d)", opts.FillFactor)
+	}
+
+	stmts := []string{
+		table,
+		"CREATE INDEX " + p.TableName + "_id ON " + p.TableName + " USING BTREE ((data->'id'))",
+		"CREATE TABLE " + p.jobsTable() + " (data jsonb)",
+		"CREATE INDEX " + p.jobsTable() + "_pending ON " + p.jobsTable() + " USING BTREE ((data->'enqueued')) WHERE data->>'state' = 'pending'",
+		"CREATE INDEX " + p.jobsTable() + "_id ON " + p.jobsTable() + " USING BTREE ((data->'id'))",
+	}
+
+	stmts = append(stmts, eventsTable(p.eventsTable(), opts.PartitionInterval)...)
+	stmts = append(stmts,
+		"CREATE TABLE "+p.expiringTable()+" (data jsonb)",
+		"CREATE INDEX "+p.expiringTable()+"_expires ON "+p.expiringTable()+" USING BTREE (((data->>'expires')::bigint))",
+		"CREATE TABLE "+p.notifyTable()+" (data jsonb)",
+		"CREATE INDEX "+p.notifyTable()+"_id ON "+p.notifyTable()+" USING BTREE ((data->'id'))",
+		"CREATE FUNCTION "+p.notifyTable()+"() RETURNS trigger AS $$ BEGIN PERFORM pg_notify('"+p.notifyTable()+"', NEW.data::text); RETURN NEW; END $$ LANGUAGE plpgsql",
+		"CREATE TRIGGER "+p.notifyTable()+" AFTER INSERT OR UPDATE ON "+p.notifyTable()+" FOR EACH ROW EXECUTE PROCEDURE "+p.notifyTable()+"()",
+		"CREATE FUNCTION "+p.timestampFunc()+"(text) RETURNS timestamptz AS $$ SELECT $1::timestamptz $$ LANGUAGE sql IMMUTABLE",
+	)
+
+	if opts.Has(schema.AgeIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_age ON "+p.TableName+" USING BTREE ((data->'age')) WHERE data->'age' > '45' AND data->'age' < '75'")
+	}
+
+	if opts.Has(schema.GINIndex) || opts.Has(schema.TagsIndex) || opts.Has(schema.AddressesIndex) {
+		switch opts.GINOpClass {
+		case "jsonb_ops", "jsonb_path_ops":
+		default:
+			return fmt.Errorf("unknown GIN operator class %q", opts.GINOpClass)
+		}
+	}
+
+	if opts.Has(schema.GINIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_gin ON "+p.TableName+" USING GIN (data "+opts.GINOpClass+")")
+	}
+
+	if opts.Has(schema.TagsIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_tags ON "+p.TableName+" USING GIN ((data->'tags') "+opts.GINOpClass+")")
+	}
+
+	if opts.Has(schema.AddressesIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_addresses ON "+p.TableName+" USING GIN ((data->'addresses') "+opts.GINOpClass+")")
+	}
+
+	if opts.Has(schema.PhoneIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_phone ON "+p.TableName+" USING BTREE ((data->>'phone_number'))")
+	}
+
+	if opts.Has(schema.PhoneColumnIndex) {
+		stmts = append(stmts,
+			"ALTER TABLE "+p.TableName+" ADD COLUMN phone_number text GENERATED ALWAYS AS (data->>'phone_number') STORED",
+			"CREATE INDEX "+p.TableName+"_phone_column ON "+p.TableName+" USING BTREE (phone_number)",
+		)
+	}
+
+	if opts.Has(schema.BalanceIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_balance ON "+p.TableName+" USING BTREE ((data->'balance'))")
+	}
+
+	if opts.Has(schema.DOBIndex) {
+		stmts = append(stmts, "CREATE INDEX "+p.TableName+"_dob ON "+p.TableName+" USING BTREE (("+p.sortKey("dob")+"))")
+	}
+
+	for _, stmt := range stmts {
+		log.Println(stmt)
+		if _, err := p.DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eventPartitions is the number of partitions created ahead of time for a
+// partitioned events table - later events are stored in the default
+// partition.
+const eventPartitions = 48
+
+// eventsTable returns the statements creating the events table named name,
+// and an index on the series and event time.
+//
+// If interval is non-zero the table is partitioned by event time, with
+// eventPartitions partitions of interval starting at the current time
+// (requires 11+).
+func eventsTable(name string, interval time.Duration) []string {
+	if interval == 0 {
+		return []string{
+			"CREATE TABLE " + name + " (data jsonb)",
+			"CREATE INDEX " + name + "_series_ts ON " + name + " USING BTREE ((data->>'series'), ((data->>'ts')::bigint))",
+		}
+	}
+
+	stmts := []string{
+		"CREATE TABLE " + name + " (data jsonb) PARTITION BY RANGE (((data->>'ts')::bigint))",
+		"CREATE INDEX " + name + "_series_ts ON " + name + " USING BTREE ((data->>'series'), ((data->>'ts')::bigint))",
+	}
+
+	start := time.Now().Truncate(interval)
+	for i := 0; i < eventPartitions; i++ {
+		from := start.Add(time.Duration(i) * interval)
+		stmts = append(stmts, fmt.Sprintf(
+			"CREATE TABLE %s_p%d PARTITION OF %s FOR VALUES FROM (%d) TO (%d)",
+			name, i, name, unixMillis(from), unixMillis(from.Add(interval)),
+		))
+	}
+
+	return append(stmts, "CREATE TABLE "+name+"_default PARTITION OF "+name+" DEFAULT")
+}
+
+// Teardown drops the table and all it's indexes, and the jobs, events,
+// expiring and notify tables.
+func (p *FuncProvider) Teardown() error {
+	_, err := p.DB.Exec("DROP TABLE IF EXISTS " + p.TableName + ", " + p.jobsTable() + ", " + p.eventsTable() + ", " + p.expiringTable() + ", " + p.notifyTable())
+	if err != nil {
+		return err
+	}
+
+	_, err = p.DB.Exec("DROP FUNCTION IF EXISTS " + p.notifyTable() + "(), " + p.timestampFunc() + "(text)")
+	return err
+}
+
+// Stats returns the table size and update statistics as name/value pairs.
+//
+// HOTUpdates counts the updates that did not require a new index entry, and
+// ToastSize is the size of the values moved out of line by TOAST. TempFiles and
+// TempBytes count the temporary files written by queries in the database, such
+// as sorts exceeding work_mem, and Deadlocks counts the deadlocks detected.
+// These counters are database wide, counted from the start of the run (see
+// StartMonitor), and may lag behind recent queries. The session settings
+// configurable in the dial string and the outcome of any transfer transactions
+// are appended.
+func (p *FuncProvider) Stats() ([][]string, error) {
+	var live, updates, hot, heap, total, toast int64
+	err := p.DB.QueryRow(`
+		SELECT s.n_live_tup, s.n_tup_upd, s.n_tup_hot_upd,
+			pg_relation_size(c.oid),
+			pg_total_relation_size(c.oid),
+			COALESCE(pg_total_relation_size(NULLIF(c.reltoastrelid, 0)), 0)
+		FROM pg_class c JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.relname = $1`,
+		p.TableName,
+	).Scan(&live, &updates, &hot, &heap, &total, &toast)
+	if err != nil {
+		return nil, err
+	}
+
+	counters, err := p.databaseCounters()
+	if err != nil {
+		return nil, err
+	}
+	tempFiles := counters.tempFiles - p.runStart.tempFiles
+	tempBytes := counters.tempBytes - p.runStart.tempBytes
+	deadlocks := counters.deadlocks - p.runStart.deadlocks
+
+	rows := [][]string{
+		{"LiveRows:", strconv.FormatInt(live, 10)},
+		{"Updates:", strconv.FormatInt(updates, 10)},
+		{"HOTUpdates:", strconv.FormatInt(hot, 10)},
+		{"HeapSize:", strconv.FormatInt(heap, 10)},
+		{"ToastSize:", strconv.FormatInt(toast, 10)},
+		{"TotalSize:", strconv.FormatInt(total, 10)},
+		{"TempFiles:", strconv.FormatInt(tempFiles, 10)},
+		{"TempBytes:", strconv.FormatInt(tempBytes, 10)},
+		{"Deadlocks:", strconv.FormatInt(deadlocks, 10)},
+	}
+
+	settings, err := p.sessionSettings()
+	if err != nil {
+		return nil, err
+	}
+	rows = append(rows, settings...)
+
+	return append(rows, p.tx.Rows()...), nil
+}
+
+// dbCounters holds the cumulative database wide statistics reported by Stats.
+type dbCounters struct {
+	tempFiles int64
+	tempBytes int64
+	deadlocks int64
+}
+
+// databaseCounters returns the current database wide statistics.
+func (p *FuncProvider) databaseCounters() (dbCounters, error) {
+	var c dbCounters
+	err := p.DB.QueryRow("SELECT temp_files, temp_bytes, deadlocks FROM pg_stat_database WHERE datname = current_database()").Scan(&c.tempFiles, &c.tempBytes, &c.deadlocks)
+	return c, err
+}
+
+// StartMonitor records the database wide statistics at the start of the run, so
+// Stats reports only those counted during the run.
+func (p *FuncProvider) StartMonitor() (stop func()) {
+	counters, err := p.databaseCounters()
+	if err != nil {
+		log.Printf("error reading database statistics: %v", err)
+	} else {
+		p.runStart = counters
+	}
+	return func() {}
+}
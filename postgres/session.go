@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setSessionOptions replaces the synchronousCommit, isolation,
+// statementTimeout and applicationName options in the endpoint query with the
+// equivalent Postgres run-time parameters, which pq sends when opening each
+// connection.
+func setSessionOptions(endpoint *url.URL) error {
+	q := endpoint.Query()
+
+	// Synchronous commit
+	switch sc := strings.ToLower(q.Get("synchronousCommit")); sc {
+	case "":
+	case "off", "local", "remote_write", "on", "remote_apply":
+		q.Set("synchronous_commit", sc)
+	default:
+		return errors.New("unknown synchronousCommit value")
+	}
+	q.Del("synchronousCommit")
+
+	// Default transaction isolation level
+	switch iso := strings.Replace(strings.ToLower(q.Get("isolation")), "-", " ", -1); iso {
+	case "":
+	case "read committed", "repeatable read", "serializable":
+		q.Set("default_transaction_isolation", iso)
+	default:
+		return errors.New("unknown isolation value")
+	}
+	q.Del("isolation")
+
+	// Statement timeout, in milliseconds - 0 disables the timeout, so shorter
+	// durations that would be truncated to 0 are rejected
+	if st := q.Get("statementTimeout"); st != "" {
+		d, err := time.ParseDuration(st)
+		if err != nil {
+			ms, err := strconv.ParseUint(st, 10, 64)
+			if err != nil {
+				return errors.New("invalid statementTimeout value")
+			}
+			d = time.Duration(ms) * time.Millisecond
+		}
+		if d < 0 || (d > 0 && d < time.Millisecond) {
+			return errors.New("statementTimeout must be 0 or at least 1ms")
+		}
+		q.Set("statement_timeout", strconv.FormatInt(int64(d/time.Millisecond), 10))
+	}
+	q.Del("statementTimeout")
+
+	// Application name
+	if name := q.Get("applicationName"); name != "" {
+		q.Set("application_name", name)
+	}
+	q.Del("applicationName")
+
+	endpoint.RawQuery = q.Encode()
+	return nil
+}
+
+// sessionSettings returns the session settings configurable in the dial string
+// as reported by the server, as name/value pairs.
+func (p *FuncProvider) sessionSettings() ([][]string, error) {
+	var syncCommit, isolation, timeout, appName string
+	err := p.DB.QueryRow(`
+		SELECT current_setting('synchronous_commit'),
+			current_setting('default_transaction_isolation'),
+			current_setting('statement_timeout'),
+			current_setting('application_name')`,
+	).Scan(&syncCommit, &isolation, &timeout, &appName)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]string{
+		{"SynchronousCommit:", syncCommit},
+		{"DefaultTransactionIsolation:", isolation},
+		{"StatementTimeout:", timeout},
+		{"ApplicationName:", appName},
+	}, nil
+}
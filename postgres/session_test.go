@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSetSessionOptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{query: "", want: ""},
+		{query: "sslmode=disable", want: "sslmode=disable"},
+		{query: "synchronousCommit=OFF", want: "synchronous_commit=off"},
+		{query: "synchronousCommit=remote_apply", want: "synchronous_commit=remote_apply"},
+		{query: "synchronousCommit=maybe", wantErr: true},
+		{query: "isolation=Repeatable-Read", want: "default_transaction_isolation=repeatable+read"},
+		{query: "isolation=read+committed", want: "default_transaction_isolation=read+committed"},
+		{query: "isolation=snapshot", wantErr: true},
+		{query: "statementTimeout=1500", want: "statement_timeout=1500"},
+		{query: "statementTimeout=2s", want: "statement_timeout=2000"},
+		{query: "statementTimeout=1ms", want: "statement_timeout=1"},
+		{query: "statementTimeout=0", want: "statement_timeout=0"},
+		{query: "statementTimeout=500us", wantErr: true},
+		{query: "statementTimeout=-1s", wantErr: true},
+		{query: "statementTimeout=-1", wantErr: true},
+		{query: "statementTimeout=soon", wantErr: true},
+		{query: "applicationName=mpjbt", want: "application_name=mpjbt"},
+	}
+
+	for _, tt := range tests {
+		u := &url.URL{Scheme: "postgres", Host: "localhost", RawQuery: tt.query}
+		err := setSessionOptions(u)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("setSessionOptions(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if err == nil && u.RawQuery != tt.want {
+			t.Errorf("setSessionOptions(%q) = %q, want %q", tt.query, u.RawQuery, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ReadSorted returns a CountFunc performing the sort query described by q,
+// returning the number of records read.
+func (p *FuncProvider) ReadSorted(q *query.TopN) plan.CountFunc {
+	stmt := p.sortStmt(q)
+
+	return func(data record.Record, _ idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		n, err := p.readRecords(data.Empty(), stmt)
+		if err != nil {
+			log.Println(err)
+			return 0, false
+		}
+
+		return n, true
+	}
+}
+
+// ExplainSort runs the sort query described by q with EXPLAIN ANALYZE,
+// returning a description of the sort method and the memory or disk space it
+// used.
+func (p *FuncProvider) ExplainSort(q *query.TopN) (string, error) {
+	var rawPlan []byte
+	if err := p.DB.QueryRow("EXPLAIN (ANALYZE, FORMAT JSON) " + p.sortStmt(q)).Scan(&rawPlan); err != nil {
+		return "", err
+	}
+
+	var explain []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(rawPlan, &explain); err != nil {
+		return "", err
+	}
+	if len(explain) == 0 {
+		return "", fmt.Errorf("empty query plan")
+	}
+
+	sorts := explain[0].Plan.sorts()
+	if len(sorts) == 0 {
+		return "no sort (index order)", nil
+	}
+	return strings.Join(sorts, ", "), nil
+}
+
+// sortStmt returns the SQL statement for q.
+func (p *FuncProvider) sortStmt(q *query.TopN) string {
+	stmt := "SELECT data FROM " + p.TableName
+	if q.Filtered {
+		stmt += " WHERE data->'enabled' = 'true'"
+	}
+
+	stmt += " ORDER BY " + p.sortKey(q.Field)
+	if q.Sort == query.Descending {
+		stmt += " DESC"
+	}
+
+	if q.Limit != 0 {
+		stmt += " LIMIT " + strconv.FormatUint(q.Limit, 10)
+	}
+	return stmt
+}
+
+// sortKey returns the expression ordering the records by field.
+//
+// The dob field is stored as an RFC3339 string, and is converted to a
+// timestamp so it's ordered by time, as the BSON date is in MongoDB.
+func (p *FuncProvider) sortKey(field string) string {
+	if field == "dob" {
+		return p.timestampFunc() + "(data->>'dob')"
+	}
+	return "(data->'" + field + "')"
+}
+
+// timestampFunc returns the name of the function converting an RFC3339 string
+// to a timestamp.
+//
+// The cast from text is not IMMUTABLE as it depends on the session time zone,
+// preventing it's use in an index - the function is declared IMMUTABLE as the
+// strings always include the UTC offset.
+func (p *FuncProvider) timestampFunc() string {
+	return p.TableName + "_timestamp"
+}
+
+// planNode is a node of a JSON formatted query plan.
+type planNode struct {
+	NodeType      string     `json:"Node Type"`
+	SortMethod    string     `json:"Sort Method"`
+	SortSpaceUsed int64      `json:"Sort Space Used"`
+	SortSpaceType string     `json:"Sort Space Type"`
+	Plans         []planNode `json:"Plans"`
+}
+
+// sorts returns a description of each sort node in the plan rooted at n.
+func (n *planNode) sorts() []string {
+	var out []string
+	if n.NodeType == "Sort" {
+		out = append(out, fmt.Sprintf("%s (%s: %dkB)", n.SortMethod, n.SortSpaceType, n.SortSpaceUsed))
+	} else if strings.HasSuffix(n.NodeType, "Sort") {
+		out = append(out, n.NodeType)
+	}
+
+	for i := range n.Plans {
+		out = append(out, n.Plans[i].sorts()...)
+	}
+	return out
+}
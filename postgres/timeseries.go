@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// eventsTable returns the name of the table used by the time-series workload.
+func (p *FuncProvider) eventsTable() string {
+	return p.TableName + "_events"
+}
+
+// AppendEvent returns a DoFunc inserting an event with the current time into
+// a random series of ts.
+//
+// The timestamp is stored as milliseconds since the epoch, which (unlike a
+// timestamptz cast) can be used as a partition key.
+func (p *FuncProvider) AppendEvent(ts query.TimeSeries) plan.DoFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) bool {
+		series := ts.RandomSeries(rnd)
+
+		_, err := p.DB.Exec(
+			"INSERT INTO "+p.eventsTable()+" (data) VALUES (jsonb_build_object('series', $1::text, 'ts', $2::bigint, 'value', $3::float8, 'tags', $4::jsonb))",
+			series.Name,
+			unixMillis(time.Now()),
+			rnd.Float64(),
+			series.TagsJSON,
+		)
+		if err != nil {
+			log.Println(series.Name, err)
+			return false
+		}
+
+		return true
+	}
+}
+
+// ReadWindow returns a CountFunc reading the events of a random series of ts
+// within the last ts.Window, returning the number of events read.
+func (p *FuncProvider) ReadWindow(ts query.TimeSeries) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		series := ts.RandomSeries(rnd).Name
+
+		rows, err := p.DB.Query(
+			"SELECT data FROM "+p.eventsTable()+" WHERE data->>'series' = $1 AND (data->>'ts')::bigint >= $2 ORDER BY (data->>'ts')::bigint",
+			series,
+			unixMillis(time.Now().Add(-ts.Window)),
+		)
+		if err != nil {
+			log.Println(series, err)
+			return 0, false
+		}
+		defer rows.Close()
+
+		var n uint64
+		var event struct {
+			Series string            `json:"series"`
+			TS     int64             `json:"ts"`
+			Value  float64           `json:"value"`
+			Tags   map[string]string `json:"tags"`
+		}
+		for rows.Next() {
+			var raw []byte
+			if err := rows.Scan(&raw); err != nil {
+				log.Println(series, err)
+				return n, false
+			}
+			if err := json.Unmarshal(raw, &event); err != nil {
+				log.Println(series, err)
+				return n, false
+			}
+			n++
+		}
+		if err := rows.Err(); err != nil {
+			log.Println(series, err)
+			return n, false
+		}
+
+		return n, true
+	}
+}
+
+// RollupWindow returns a CountFunc computing the per-minute event count and
+// average value of a random series of ts within the last ts.Window, returning
+// the number of minutes read.
+func (p *FuncProvider) RollupWindow(ts query.TimeSeries) plan.CountFunc {
+	return func(_ record.Record, _ idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		series := ts.RandomSeries(rnd).Name
+
+		rows, err := p.DB.Query(`
+			SELECT (data->>'ts')::bigint / 60000 AS minute, count(*), avg((data->>'value')::float8)
+			FROM `+p.eventsTable()+`
+			WHERE data->>'series' = $1 AND (data->>'ts')::bigint >= $2
+			GROUP BY minute
+			ORDER BY minute`,
+			series,
+			unixMillis(time.Now().Add(-ts.Window)),
+		)
+		if err != nil {
+			log.Println(series, err)
+			return 0, false
+		}
+		defer rows.Close()
+
+		var (
+			n      uint64
+			minute int64
+			count  uint64
+			avg    float64
+		)
+		for rows.Next() {
+			if err := rows.Scan(&minute, &count, &avg); err != nil {
+				log.Println(series, err)
+				return n, false
+			}
+			n++
+		}
+		if err := rows.Err(); err != nil {
+			log.Println(series, err)
+			return n, false
+		}
+
+		return n, true
+	}
+}
+
+// EventsSize returns the number of events in the events table, and it's total
+// size in bytes including indexes and TOAST, summed over any partitions.
+//
+// The number of events is the live row estimate maintained by the statistics
+// collector rather than a count, so sampling does not scan the table.
+func (p *FuncProvider) EventsSize() (uint64, uint64, error) {
+	var rows, size uint64
+	err := p.DB.QueryRow(`
+		SELECT COALESCE(sum(n_live_tup), 0)::bigint, COALESCE(sum(pg_total_relation_size(relid)), 0)::bigint
+		FROM pg_stat_user_tables
+		WHERE relid = $1::regclass OR relid IN (SELECT inhrelid FROM pg_inherits WHERE inhparent = $1::regclass)`,
+		p.eventsTable(),
+	).Scan(&rows, &size)
+	return rows, size, err
+}
+
+// unixMillis returns t as milliseconds since the epoch.
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
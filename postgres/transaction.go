@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+	"github.com/lib/pq"
+)
+
+// errConflict is returned by transfer when the transaction failed with a
+// serialization failure or deadlock and should be retried.
+var errConflict = errors.New("transaction conflict")
+
+// Transfer returns a CountFunc moving t.Amount of balance between two records
+// with IDs returned by id.GetExisting, returning the number of times the
+// transaction was retried.
+//
+// Both balances are read and then updated within a transaction at
+// t.Isolation, or the connection's default_transaction_isolation if
+// sql.LevelDefault - at read committed a concurrent transfer can overwrite the
+// update (a lost update), at repeatable read and serializable Postgres aborts
+// the transaction with a serialization failure instead. Transactions failing
+// with a serialization failure or deadlock are retried up to t.MaxRetries
+// times.
+func (p *FuncProvider) Transfer(t query.Transfer) plan.CountFunc {
+	return func(_ record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+		from, to := query.TransferIDs(id)
+
+		for retries := 0; ; retries++ {
+			err := p.transfer(t, from, to)
+			if err == nil {
+				return uint64(retries), true
+			}
+
+			if err != errConflict {
+				log.Println(from, to, err)
+				p.tx.Abort()
+				return uint64(retries), false
+			}
+
+			if retries == t.MaxRetries {
+				log.Println(from, to, "transaction aborted after", retries, "retries")
+				p.tx.Abort()
+				return uint64(retries), false
+			}
+			p.tx.Retry()
+		}
+	}
+}
+
+// transfer performs a single attempt of the transfer from one record to
+// another.
+func (p *FuncProvider) transfer(t query.Transfer, from, to uint64) error {
+	start := time.Now()
+	tx, err := p.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: t.Isolation})
+	if err != nil {
+		return err
+	}
+
+	err = p.moveBalance(tx, from, to, t.Amount)
+	if err != nil {
+		_ = tx.Rollback()
+		return txError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return txError(err)
+	}
+	p.tx.Commit(time.Since(start))
+
+	return nil
+}
+
+// moveBalance reads the balance of both records and writes the adjusted
+// values back within tx.
+//
+// The records are updated in ID order so concurrent transfers between the
+// same records do not deadlock.
+func (p *FuncProvider) moveBalance(tx *sql.Tx, from, to uint64, amount float64) error {
+	var fromBalance, toBalance float64
+	read := "SELECT (data->>'balance')::float8 FROM " + p.TableName + " WHERE data->'id'=$1"
+	if err := tx.QueryRow(read, from).Scan(&fromBalance); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(read, to).Scan(&toBalance); err != nil {
+		return err
+	}
+
+	updates := []struct {
+		id      uint64
+		balance float64
+	}{
+		{from, fromBalance - amount},
+		{to, toBalance + amount},
+	}
+	if to < from {
+		updates[0], updates[1] = updates[1], updates[0]
+	}
+
+	for _, u := range updates {
+		_, err := tx.Exec(
+			"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{balance}', to_jsonb($1::float8), false) WHERE data->'id'=$2",
+			u.balance,
+			u.id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// txError returns errConflict if err is a serialization failure (40001) or
+// deadlock (40P01), or err otherwise.
+func txError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "40001", "40P01":
+			return errConflict
+		}
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AgeBucketWidth is the width of the age buckets balances are averaged over.
+const AgeBucketWidth = 10
+
+// EnabledCount is a result row of the count by enabled aggregation.
+type EnabledCount struct {
+	Enabled bool  `bson:"_id"`
+	Count   int64 `bson:"count"`
+}
+
+// AgeBucket is a result row of the average balance by age aggregation.
+//
+// Age is the inclusive lower bound of the bucket, a multiple of
+// AgeBucketWidth.
+type AgeBucket struct {
+	Age        int64   `bson:"_id"`
+	AvgBalance float64 `bson:"avg"`
+}
+
+// CounterRank is a result row of the top-N by counter aggregation.
+type CounterRank struct {
+	ID      uint64 `bson:"_id"`
+	Counter int64  `bson:"counter"`
+}
+
+// Both providers implement the aggregations with the same semantics - records
+// without the grouped or sorted field are ignored. The Verify functions below
+// check the properties of each result that hold regardless of the data, and the
+// Compare functions check the results of both providers for the same data
+// match.
+
+// VerifyEnabledCounts checks rows contains at most one count for each value of
+// enabled.
+func VerifyEnabledCounts(rows []EnabledCount) error {
+	if len(rows) > 2 {
+		return fmt.Errorf("got %d enabled groups, want at most 2", len(rows))
+	}
+	if len(rows) == 2 && rows[0].Enabled == rows[1].Enabled {
+		return errors.New("duplicate enabled group")
+	}
+	for _, r := range rows {
+		if r.Count < 1 {
+			return fmt.Errorf("enabled=%v group has count %d", r.Enabled, r.Count)
+		}
+	}
+	return nil
+}
+
+// VerifyAgeBuckets checks rows is ordered by age, and each bucket is a multiple
+// of AgeBucketWidth.
+func VerifyAgeBuckets(rows []AgeBucket) error {
+	for i, r := range rows {
+		if r.Age%AgeBucketWidth != 0 {
+			return fmt.Errorf("age bucket %d is not a multiple of %d", r.Age, AgeBucketWidth)
+		}
+		if i > 0 && r.Age <= rows[i-1].Age {
+			return fmt.Errorf("age bucket %d out of order", r.Age)
+		}
+	}
+	return nil
+}
+
+// VerifyTopCounters checks rows contains at most n records, ordered by counter
+// (highest first) and then by ID (highest first).
+func VerifyTopCounters(rows []CounterRank, n uint64) error {
+	if uint64(len(rows)) > n {
+		return fmt.Errorf("got %d records, want at most %d", len(rows), n)
+	}
+	for i := 1; i < len(rows); i++ {
+		prev, r := rows[i-1], rows[i]
+		if r.Counter > prev.Counter || (r.Counter == prev.Counter && r.ID >= prev.ID) {
+			return fmt.Errorf("record %d out of order", r.ID)
+		}
+	}
+	return nil
+}
+
+// CompareEnabledCounts checks a and b contain the same count for each value of
+// enabled, in any order.
+func CompareEnabledCounts(a, b []EnabledCount) error {
+	counts := func(rows []EnabledCount) map[bool]int64 {
+		m := map[bool]int64{}
+		for _, r := range rows {
+			m[r.Enabled] += r.Count
+		}
+		return m
+	}
+
+	ca, cb := counts(a), counts(b)
+	for _, enabled := range []bool{true, false} {
+		if ca[enabled] != cb[enabled] {
+			return fmt.Errorf("enabled=%v group has counts %d and %d", enabled, ca[enabled], cb[enabled])
+		}
+	}
+	return nil
+}
+
+// balanceTolerance is the relative difference allowed between two average
+// balances, as the providers may sum the balances in a different order.
+const balanceTolerance = 1e-9
+
+// CompareAgeBuckets checks a and b contain the same age buckets in the same
+// order, with approximately equal average balances.
+func CompareAgeBuckets(a, b []AgeBucket) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("got %d and %d age buckets", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].Age != b[i].Age {
+			return fmt.Errorf("age bucket %d is %d and %d", i, a[i].Age, b[i].Age)
+		}
+
+		diff := math.Abs(a[i].AvgBalance - b[i].AvgBalance)
+		if diff > balanceTolerance*math.Max(1, math.Abs(a[i].AvgBalance)) {
+			return fmt.Errorf("age bucket %d has average balances %v and %v", a[i].Age, a[i].AvgBalance, b[i].AvgBalance)
+		}
+	}
+	return nil
+}
+
+// CompareTopCounters checks a and b contain the same records in the same
+// order.
+func CompareTopCounters(a, b []CounterRank) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("got %d and %d records", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return fmt.Errorf("rank %d is record %d (counter %d) and record %d (counter %d)", i+1, a[i].ID, a[i].Counter, b[i].ID, b[i].Counter)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package query
+
+import "testing"
+
+func TestVerifyEnabledCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []EnabledCount
+		ok   bool
+	}{
+		{"empty", nil, true},
+		{"both", []EnabledCount{{true, 8}, {false, 2}}, true},
+		{"duplicate", []EnabledCount{{true, 8}, {true, 2}}, false},
+		{"zero count", []EnabledCount{{true, 0}}, false},
+		{"too many", []EnabledCount{{true, 1}, {false, 1}, {true, 1}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := VerifyEnabledCounts(tt.rows); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestVerifyAgeBuckets(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []AgeBucket
+		ok   bool
+	}{
+		{"ordered", []AgeBucket{{10, 0.1}, {20, -0.2}, {40, 0}}, true},
+		{"unordered", []AgeBucket{{20, 0}, {10, 0}}, false},
+		{"duplicate", []AgeBucket{{10, 0}, {10, 0}}, false},
+		{"not aligned", []AgeBucket{{15, 0}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := VerifyAgeBuckets(tt.rows); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestVerifyTopCounters(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []CounterRank
+		ok   bool
+	}{
+		{"ordered", []CounterRank{{1, 999}, {7, 998}, {3, 998}}, true},
+		{"unordered counter", []CounterRank{{1, 5}, {2, 6}}, false},
+		{"unordered tie", []CounterRank{{1, 5}, {2, 5}}, false},
+		{"too many", []CounterRank{{4, 3}, {3, 2}, {2, 1}, {1, 0}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := VerifyTopCounters(tt.rows, 3); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestCompareEnabledCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []EnabledCount
+		ok   bool
+	}{
+		{"empty", nil, nil, true},
+		{"reordered", []EnabledCount{{true, 8}, {false, 2}}, []EnabledCount{{false, 2}, {true, 8}}, true},
+		{"different count", []EnabledCount{{true, 8}}, []EnabledCount{{true, 7}}, false},
+		{"missing group", []EnabledCount{{true, 8}, {false, 2}}, []EnabledCount{{true, 8}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := CompareEnabledCounts(tt.a, tt.b); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestCompareAgeBuckets(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []AgeBucket
+		ok   bool
+	}{
+		{"equal", []AgeBucket{{10, 0.1}, {20, -0.2}}, []AgeBucket{{10, 0.1}, {20, -0.2}}, true},
+		{"rounding", []AgeBucket{{10, 0.1}}, []AgeBucket{{10, 0.1 + 1e-15}}, true},
+		{"different average", []AgeBucket{{10, 0.1}}, []AgeBucket{{10, 0.2}}, false},
+		{"different age", []AgeBucket{{10, 0.1}}, []AgeBucket{{20, 0.1}}, false},
+		{"missing bucket", []AgeBucket{{10, 0.1}, {20, 0}}, []AgeBucket{{10, 0.1}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := CompareAgeBuckets(tt.a, tt.b); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestCompareTopCounters(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []CounterRank
+		ok   bool
+	}{
+		{"equal", []CounterRank{{1, 999}, {7, 998}}, []CounterRank{{1, 999}, {7, 998}}, true},
+		{"reordered", []CounterRank{{1, 999}, {7, 998}}, []CounterRank{{7, 998}, {1, 999}}, false},
+		{"different counter", []CounterRank{{1, 999}}, []CounterRank{{1, 998}}, false},
+		{"missing record", []CounterRank{{1, 999}, {7, 998}}, []CounterRank{{1, 999}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := CompareTopCounters(tt.a, tt.b); (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
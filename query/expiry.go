@@ -0,0 +1,7 @@
+package query
+
+import "errors"
+
+// ErrServerExpiry is returned when purging expired records by a database that
+// removes them itself.
+var ErrServerExpiry = errors.New("expired records are removed by the server")
@@ -0,0 +1,18 @@
+package query
+
+import "strconv"
+
+// VerifyIncrements compares the change in the sum of the incremented counters
+// (delta) with the number of increments reported as successful, returning
+// the outcome as name/value pairs.
+//
+// LostUpdates is the number of successful increments missing from the
+// counters. A negative value means the counters changed more than expected,
+// such as an increment that failed client side after being applied.
+func VerifyIncrements(delta int64, increments uint64) [][]string {
+	return [][]string{
+		{"Increments:", strconv.FormatUint(increments, 10)},
+		{"CounterDelta:", strconv.FormatInt(delta, 10)},
+		{"LostUpdates:", strconv.FormatInt(int64(increments)-delta, 10)},
+	}
+}
@@ -0,0 +1,23 @@
+package query
+
+import "testing"
+
+func TestVerifyIncrements(t *testing.T) {
+	tests := []struct {
+		delta      int64
+		increments uint64
+		want       string
+	}{
+		{delta: 100, increments: 100, want: "0"},
+		{delta: 90, increments: 100, want: "10"},
+		{delta: 101, increments: 100, want: "-1"},
+	}
+
+	for _, tt := range tests {
+		rows := VerifyIncrements(tt.delta, tt.increments)
+		lost := rows[len(rows)-1]
+		if lost[0] != "LostUpdates:" || lost[1] != tt.want {
+			t.Errorf("VerifyIncrements(%d, %d) = %v, want LostUpdates %s", tt.delta, tt.increments, lost, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package query
+
+import "time"
+
+// Notification is written by the notify workload, and delivered to
+// subscribers when it is inserted or updated.
+type Notification struct {
+	ID uint64 `bson:"_id" json:"id"`
+
+	// Version is 1 when the notification is inserted, and incremented each
+	// time it is updated.
+	Version uint64 `bson:"version" json:"version"`
+
+	// Sent is the time the notification was written, in nanoseconds since
+	// the epoch.
+	Sent int64 `bson:"sent" json:"sent"`
+}
+
+// NewNotification returns a Notification with the current time.
+func NewNotification(id, version uint64) Notification {
+	return Notification{
+		ID:      id,
+		Version: version,
+		Sent:    time.Now().UnixNano(),
+	}
+}
+
+// Delay returns the time between n being sent and now.
+func (n Notification) Delay() time.Duration {
+	return time.Since(time.Unix(0, n.Sent))
+}
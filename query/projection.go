@@ -0,0 +1,7 @@
+package query
+
+// Projection is the subset of record fields read by the projection workloads.
+type Projection struct {
+	Name    string  `bson:"name"    json:"name"`
+	Balance float64 `bson:"balance" json:"balance"`
+}
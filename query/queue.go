@@ -0,0 +1,21 @@
+package query
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQueueEmpty is returned when claiming a job finds no pending jobs.
+var ErrQueueEmpty = errors.New("no pending jobs")
+
+// Job is a job claimed by the queue workload.
+type Job struct {
+	ID uint64
+
+	// Token identifies the claim, and must match the token stored with the job
+	// to complete it.
+	Token uint64
+
+	// Queued is the time between the job being enqueued and claimed.
+	Queued time.Duration
+}
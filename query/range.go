@@ -0,0 +1,184 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// numSamples is the number of field values sampled from the database to
+// calculate random and selectivity bounds.
+const numSamples = 10000
+
+// Fields lists the record fields a Range can be performed on.
+var Fields = []string{"age", "balance", "counter"}
+
+// Sort defines the order of the records returned by a range query.
+type Sort int
+
+const (
+	// Unsorted returns records in whatever order the database decides.
+	Unsorted Sort = iota
+	// Ascending orders records by the range field, smallest first.
+	Ascending
+	// Descending orders records by the range field, largest first.
+	Descending
+)
+
+// Range describes a range query on a single numeric record field.
+//
+// Records with a Field value greater than the lower bound and less than the
+// upper bound are returned.
+type Range struct {
+	Field  string
+	Bounds Bounds
+
+	// Limit is the maximum number of records to return (0 == unlimited).
+	Limit uint64
+	Sort  Sort
+}
+
+// Bounds returns the lower and upper bound of a range query.
+//
+// Implementations must be safe for concurrent use.
+type Bounds interface {
+	Next(rnd *rand.Rand) (lo, hi float64)
+}
+
+// FixedBounds always returns Lo and Hi.
+type FixedBounds struct {
+	Lo, Hi float64
+}
+
+// Next returns Lo and Hi.
+func (b *FixedBounds) Next(_ *rand.Rand) (float64, float64) {
+	return b.Lo, b.Hi
+}
+
+// RandomBounds returns two uniformly distributed random values between Min and
+// Max, lowest first.
+type RandomBounds struct {
+	Min, Max float64
+}
+
+// Next returns a random lower and upper bound.
+func (b *RandomBounds) Next(rnd *rand.Rand) (float64, float64) {
+	lo := b.Min + rnd.Float64()*(b.Max-b.Min)
+	hi := b.Min + rnd.Float64()*(b.Max-b.Min)
+	if lo > hi {
+		return hi, lo
+	}
+	return lo, hi
+}
+
+// SelectivityBounds returns a random range covering approximately Fraction of
+// the records.
+//
+// Bounds are chosen using the empirical distribution of Samples, so the
+// selectivity holds regardless of how the field values are distributed.
+type SelectivityBounds struct {
+	// Samples must be sorted in ascending order.
+	Samples  []float64
+	Fraction float64
+}
+
+// Next returns a random range selecting approximately Fraction of the records.
+func (b *SelectivityBounds) Next(rnd *rand.Rand) (float64, float64) {
+	n := len(b.Samples)
+	width := int(b.Fraction * float64(n))
+	if width >= n {
+		return b.Samples[0] - 1, b.Samples[n-1] + 1
+	}
+
+	start := rnd.Intn(n - width)
+	return b.Samples[start], b.Samples[start+width]
+}
+
+// SampleFunc returns n field values from existing records.
+type SampleFunc func(n int) ([]float64, error)
+
+// ParseBounds returns the Bounds described by spec, calling sample to read
+// existing field values if needed.
+//
+// Valid specs are:
+//
+//	"45:75"  - fixed lower and upper bounds
+//	"random" - random bounds between the smallest and largest sampled values
+//	"5%"     - random bounds selecting approximately 5% of the records
+func ParseBounds(spec string, sample SampleFunc) (Bounds, error) {
+	switch {
+	case spec == "random":
+		samples, err := sortedSamples(sample)
+		if err != nil {
+			return nil, err
+		}
+		return &RandomBounds{Min: samples[0], Max: samples[len(samples)-1]}, nil
+
+	case strings.HasSuffix(spec, "%"):
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return nil, fmt.Errorf("invalid selectivity %q", spec)
+		}
+
+		samples, err := sortedSamples(sample)
+		if err != nil {
+			return nil, err
+		}
+		return &SelectivityBounds{Samples: samples, Fraction: pct / 100}, nil
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid bounds %q", spec)
+	}
+
+	lo, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lower bound: %v", err)
+	}
+	hi, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upper bound: %v", err)
+	}
+
+	return &FixedBounds{Lo: lo, Hi: hi}, nil
+}
+
+func sortedSamples(sample SampleFunc) ([]float64, error) {
+	samples, err := sample(numSamples)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no existing data to sample")
+	}
+
+	sort.Float64s(samples)
+	return samples, nil
+}
+
+// ParseField returns an error if name is not one of Fields.
+func ParseField(name string) error {
+	for _, f := range Fields {
+		if f == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown range field %q, valid: %s", name, strings.Join(Fields, " "))
+}
+
+// ParseSort returns the Sort described by s (none, asc or desc).
+func ParseSort(s string) (Sort, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return Unsorted, nil
+	case "asc":
+		return Ascending, nil
+	case "desc":
+		return Descending, nil
+	}
+	return Unsorted, fmt.Errorf("unknown sort order %q, valid: none asc desc", s)
+}
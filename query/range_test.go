@@ -0,0 +1,72 @@
+package query
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func sequentialSamples(n int) ([]float64, error) {
+	out := make([]float64, n)
+	for i := range out {
+		// Reverse order to ensure ParseBounds sorts
+		out[i] = float64(n - i)
+	}
+	return out, nil
+}
+
+func TestParseBounds_Fixed(t *testing.T) {
+	b, err := ParseBounds("45:75", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lo, hi := b.Next(nil)
+	if lo != 45 || hi != 75 {
+		t.Errorf("got (%v, %v), want (45, 75)", lo, hi)
+	}
+}
+
+func TestParseBounds_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "45", "a:75", "45:b", "0%", "101%", "x%"} {
+		if _, err := ParseBounds(spec, sequentialSamples); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestParseBounds_Random(t *testing.T) {
+	b, err := ParseBounds("random", sequentialSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		lo, hi := b.Next(rnd)
+		if lo > hi || lo < 1 || hi > numSamples {
+			t.Fatalf("got (%v, %v), want 1 <= lo <= hi <= %d", lo, hi, numSamples)
+		}
+	}
+}
+
+func TestParseBounds_Selectivity(t *testing.T) {
+	b, err := ParseBounds("5%", sequentialSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		lo, hi := b.Next(rnd)
+		if got, want := hi-lo, float64(numSamples)*0.05; got != want {
+			t.Fatalf("got width %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseBounds_NoData(t *testing.T) {
+	empty := func(n int) ([]float64, error) { return nil, nil }
+	if _, err := ParseBounds("random", empty); err == nil {
+		t.Error("expected error")
+	}
+}
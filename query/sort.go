@@ -0,0 +1,37 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortFields lists the record fields a TopN query can order by.
+var SortFields = []string{"balance", "dob"}
+
+// TopN describes a query returning the first Limit records ordered by a
+// non-key field.
+type TopN struct {
+	Field string
+	Sort  Sort
+
+	// Limit is the maximum number of records to return (0 == unlimited).
+	Limit uint64
+
+	// Filtered restricts the query to enabled records.
+	Filtered bool
+
+	// AllowDisk allows MongoDB to sort using temporary files when the sort
+	// exceeds the in-memory limit, instead of returning an error. Postgres
+	// always spills to disk when a sort exceeds work_mem.
+	AllowDisk bool
+}
+
+// ParseSortField returns an error if name is not in SortFields.
+func ParseSortField(name string) error {
+	for _, f := range SortFields {
+		if f == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sort field %q, valid: %s", name, strings.Join(SortFields, " "))
+}
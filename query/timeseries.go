@@ -0,0 +1,65 @@
+package query
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// regions are assigned to the series in turn as a tag.
+var regions = []string{"eu-west", "eu-central", "us-east", "us-west"}
+
+// Series is an event stream, and the tags attached to each of it's events.
+type Series struct {
+	Name string
+	Tags map[string]string
+
+	// TagsJSON is Tags encoded as a JSON object.
+	TagsJSON string
+}
+
+// TimeSeries describes the event streams appended to and queried by the
+// time-series workload.
+type TimeSeries struct {
+	// Series is the number of distinct event streams.
+	Series int
+
+	// Window is the duration of recent events read by the window queries.
+	Window time.Duration
+
+	// series holds each stream, generated once so the measured calls do not
+	// format or encode them.
+	series []Series
+}
+
+// NewTimeSeries returns a TimeSeries of n event streams, with window queries
+// reading the events in the last window.
+func NewTimeSeries(n int, window time.Duration) TimeSeries {
+	ts := TimeSeries{Series: n, Window: window, series: make([]Series, n)}
+	for i := range ts.series {
+		tags := map[string]string{
+			"host":   "host-" + strconv.Itoa(i),
+			"region": regions[i%len(regions)],
+		}
+
+		tagData, err := json.Marshal(tags)
+		if err != nil {
+			panic(err)
+		}
+
+		ts.series[i] = Series{Name: SeriesName(i), Tags: tags, TagsJSON: string(tagData)}
+	}
+	return ts
+}
+
+// RandomSeries returns a random series - the returned Series is shared and must
+// not be modified.
+func (t TimeSeries) RandomSeries(rnd *rand.Rand) *Series {
+	return &t.series[rnd.Intn(len(t.series))]
+}
+
+// SeriesName returns the name of series n.
+func SeriesName(n int) string {
+	return "series-" + strconv.Itoa(n)
+}
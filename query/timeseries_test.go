@@ -0,0 +1,40 @@
+package query
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTimeSeries_RandomSeries(t *testing.T) {
+	ts := NewTimeSeries(3, 0)
+	rnd := rand.New(rand.NewSource(42))
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		s := ts.RandomSeries(rnd)
+		seen[s.Name] = true
+
+		if s.Tags["host"] == "" || s.Tags["region"] == "" {
+			t.Fatalf("series %s missing tags: %v", s.Name, s.Tags)
+		}
+
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(s.TagsJSON), &tags); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tags, s.Tags) {
+			t.Fatalf("series %s got tags JSON %s, want %v", s.Name, s.TagsJSON, s.Tags)
+		}
+	}
+
+	for i := 0; i < ts.Series; i++ {
+		if !seen[SeriesName(i)] {
+			t.Errorf("series %s never returned", SeriesName(i))
+		}
+	}
+	if len(seen) != ts.Series {
+		t.Errorf("got %d distinct series, want %d", len(seen), ts.Series)
+	}
+}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/domodwyer/mpjbt/idgen"
+)
+
+// Transfer describes a transaction moving Amount of balance between two
+// records using a read-modify-write.
+type Transfer struct {
+	Amount float64
+
+	// Isolation is the Postgres transaction isolation level, ignored by
+	// MongoDB.
+	Isolation sql.IsolationLevel
+
+	// MaxRetries is the number of times a transaction failing due to a
+	// conflict is retried before it is aborted.
+	MaxRetries int
+}
+
+// ParseIsolation returns the isolation level described by s (read-committed,
+// repeatable-read or serializable), or sql.LevelDefault if s is empty so the
+// connection's default_transaction_isolation applies.
+func ParseIsolation(s string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read-committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable-read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return sql.LevelDefault, fmt.Errorf("unknown isolation level %q, valid: read-committed repeatable-read serializable", s)
+}
+
+// TransferIDs returns two distinct existing IDs from id to transfer between,
+// retrying a few times to avoid picking the same record twice.
+//
+// If id keeps returning the same ID (such as a zipfian generator over few
+// records), the adjacent ID is used instead as the IDs are contiguous from 1 -
+// there must be at least 2 records.
+func TransferIDs(id idgen.Generator) (from, to uint64) {
+	from = id.GetExisting()
+	to = id.GetExisting()
+	for i := 0; to == from && i < 10; i++ {
+		to = id.GetExisting()
+	}
+
+	if to == from {
+		to = from - 1
+		if from <= 1 {
+			to = from + 1
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// fixedID is an idgen.Generator always returning the same ID.
+type fixedID uint64
+
+func (f fixedID) GetNew() uint64      { return uint64(f) }
+func (f fixedID) GetExisting() uint64 { return uint64(f) }
+
+func TestParseIsolation(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    sql.IsolationLevel
+		wantErr bool
+	}{
+		{in: "", want: sql.LevelDefault},
+		{in: "read-committed", want: sql.LevelReadCommitted},
+		{in: "Repeatable-Read", want: sql.LevelRepeatableRead},
+		{in: "serializable", want: sql.LevelSerializable},
+		{in: "snapshot", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseIsolation(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIsolation(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseIsolation(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTransferIDs_Distinct(t *testing.T) {
+	tests := []struct {
+		id       fixedID
+		from, to uint64
+	}{
+		{id: 1, from: 1, to: 2},
+		{id: 42, from: 42, to: 41},
+	}
+
+	for _, tt := range tests {
+		from, to := TransferIDs(tt.id)
+		if from != tt.from || to != tt.to {
+			t.Errorf("TransferIDs(%d) = %d, %d, want %d, %d", tt.id, from, to, tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/query"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// queueWorker is the idgen.Generator of a queue workload worker, holding the
+// job it last claimed until it is completed.
+type queueWorker struct {
+	idgen.Generator
+
+	job     query.Job
+	claimed bool
+}
+
+// queueWorkerSource returns a queueWorker for each worker, generating job IDs
+// from the embedded GeneratorSource.
+type queueWorkerSource struct {
+	idgen.GeneratorSource
+}
+
+// New returns a queueWorker with no claimed job.
+func (s queueWorkerSource) New() idgen.Generator {
+	return &queueWorker{Generator: s.GeneratorSource.New()}
+}
+
+// queueOps claims and completes jobs on behalf of each queueWorker.
+type queueOps struct {
+	db dbProvider
+
+	// tokens is the last claim token issued.
+	tokens uint64
+
+	// claimed counts the jobs claimed during the run.
+	claimed uint64
+}
+
+// claim claims the oldest pending job with a new claim token, returning the
+// time it spent queued in microseconds. The job is held by the worker's
+// queueWorker until complete is called.
+//
+// id must be a queueWorker.
+func (q *queueOps) claim(_ record.Record, id idgen.Generator, _ *rand.Rand) (uint64, bool) {
+	w := id.(*queueWorker)
+	w.claimed = false
+
+	job, err := q.db.ClaimJob(atomic.AddUint64(&q.tokens, 1))
+	if err == query.ErrQueueEmpty {
+		return 0, false
+	}
+	if err != nil {
+		log.Println(err)
+		return 0, false
+	}
+
+	atomic.AddUint64(&q.claimed, 1)
+	w.job, w.claimed = job, true
+	return uint64(job.Queued / time.Microsecond), true
+}
+
+// complete marks the job held by the worker's queueWorker as done, returning
+// false if it holds no job or the job was claimed by another worker since.
+//
+// id must be a queueWorker.
+func (q *queueOps) complete(_ record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	w := id.(*queueWorker)
+	if !w.claimed {
+		return false
+	}
+	w.claimed = false
+
+	if err := q.db.CompleteJob(w.job); err != nil {
+		log.Println(w.job.ID, err)
+		return false
+	}
+	return true
+}
+
+// rows returns the number of jobs claimed during the run as name/value pairs.
+func (q *queueOps) rows() [][]string {
+	return [][]string{
+		{"JobsClaimedDuringRun:", strconv.FormatUint(atomic.LoadUint64(&q.claimed), 10)},
+	}
+}
@@ -0,0 +1,33 @@
+package record
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"time"
+)
+
+// Checksum returns a hash of the stored content of r, used to compare a record
+// read from a database with the record that was written.
+//
+// Person values are normalised to the precision stored by both databases -
+// dates are truncated to milliseconds in UTC (the BSON date precision), and
+// empty slices are treated as absent.
+func Checksum(r Record) uint64 {
+	if p, ok := r.(*Person); ok {
+		n := *p
+		n.DateOfBirth = p.DateOfBirth.Truncate(time.Millisecond).UTC()
+		if len(n.Address) == 0 {
+			n.Address = nil
+		}
+		if len(n.Padding.Data) == 0 {
+			n.Padding.Data = nil
+		}
+		r = &n
+	}
+
+	h := fnv.New64a()
+	if err := json.NewEncoder(h).Encode(r); err != nil {
+		panic(err)
+	}
+	return h.Sum64()
+}
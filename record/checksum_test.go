@@ -0,0 +1,40 @@
+package record
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestChecksum(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	p := NewPerson(FixedSize(64), nil, nil)
+	p.Randomise(rnd)
+	p.SetID(42)
+	p.Address = []Address{}
+	want := Checksum(p)
+
+	// A JSON round trip (with the dates stored at millisecond precision)
+	// must not change the checksum.
+	raw, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.Empty().(*Person)
+	if err := json.Unmarshal(raw, got); err != nil {
+		t.Fatal(err)
+	}
+	got.DateOfBirth = got.DateOfBirth.Truncate(time.Millisecond)
+	got.Address = nil
+
+	if sum := Checksum(got); sum != want {
+		t.Errorf("checksum changed after round trip: got %x, want %x", sum, want)
+	}
+
+	got.Balance++
+	if sum := Checksum(got); sum == want {
+		t.Error("checksum unchanged after modifying the balance")
+	}
+}
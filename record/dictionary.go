@@ -0,0 +1,80 @@
+package record
+
+import "strconv"
+
+// firstNames, lastNames, streets, streetSuffixes and towns are combined to
+// build dictionaries of plausible values with a configurable cardinality.
+var (
+	firstNames = []string{
+		"Oliver", "Amelia", "George", "Isla", "Harry", "Ava", "Noah", "Mia",
+		"Jack", "Ivy", "Leo", "Lily", "Arthur", "Isabella", "Muhammad", "Rosie",
+		"Oscar", "Sophia", "Charlie", "Grace", "Jacob", "Willow", "Thomas", "Freya",
+		"Henry", "Florence", "William", "Emily", "James", "Ella", "Joshua", "Poppy",
+		"Alfie", "Evie", "Archie", "Elsie", "Freddie", "Charlotte", "Isaac", "Evelyn",
+		"Alexander", "Sienna", "Theo", "Sofia", "Lucas", "Daisy", "Max", "Phoebe",
+		"Finley", "Sophie", "Logan", "Alice", "Ethan", "Harper", "Edward", "Matilda",
+		"Daniel", "Ruby", "Samuel", "Emilia", "Adam", "Maya", "Benjamin", "Millie",
+	}
+
+	lastNames = []string{
+		"Smith", "Jones", "Williams", "Taylor", "Brown", "Davies", "Evans", "Wilson",
+		"Thomas", "Johnson", "Roberts", "Robinson", "Thompson", "Wright", "Walker", "White",
+		"Edwards", "Hughes", "Green", "Hall", "Lewis", "Harris", "Clarke", "Patel",
+		"Jackson", "Wood", "Turner", "Martin", "Cooper", "Hill", "Ward", "Morris",
+		"Moore", "Clark", "Lee", "King", "Baker", "Harrison", "Morgan", "Allen",
+		"James", "Scott", "Phillips", "Watson", "Davis", "Parker", "Price", "Bennett",
+		"Young", "Griffiths", "Mitchell", "Kelly", "Cook", "Carter", "Richardson", "Bailey",
+		"Collins", "Bell", "Shaw", "Murphy", "Miller", "Cox", "Richards", "Khan",
+	}
+
+	streets = []string{
+		"High", "Station", "Main", "Park", "Church", "London", "Victoria", "Green",
+		"Manor", "Queens", "Kings", "Grange", "Mill", "School", "Springfield", "Windsor",
+		"Highfield", "Albert", "New", "Alexandra", "York", "North", "South", "West",
+		"East", "Chapel", "Richmond", "Stanley", "George", "Orchard", "Beech", "Oak",
+	}
+
+	streetSuffixes = []string{
+		"Street", "Road", "Lane", "Avenue", "Close", "Drive", "Way", "Gardens",
+	}
+
+	towns = []string{
+		"London", "Birmingham", "Manchester", "Leeds", "Glasgow", "Sheffield", "Bradford", "Liverpool",
+		"Edinburgh", "Bristol", "Cardiff", "Leicester", "Coventry", "Nottingham", "Newcastle", "Belfast",
+		"Brighton", "Hull", "Plymouth", "Stoke", "Wolverhampton", "Derby", "Swansea", "Southampton",
+		"Salford", "Aberdeen", "Westminster", "Portsmouth", "York", "Peterborough", "Dundee", "Lancaster",
+		"Oxford", "Newport", "Preston", "St Albans", "Norwich", "Chester", "Cambridge", "Salisbury",
+		"Exeter", "Gloucester", "Lisburn", "Chichester", "Winchester", "Londonderry", "Carlisle", "Worcester",
+		"Bath", "Durham", "Lincoln", "Hereford", "Armagh", "Inverness", "Stirling", "Canterbury",
+		"Lichfield", "Newry", "Ripon", "Bangor", "Truro", "Ely", "Wakefield", "Wells",
+	}
+)
+
+// buildNames returns n distinct full names.
+//
+// Once every first and last name combination is used, a numeric suffix is
+// appended to keep the names distinct.
+func buildNames(n int) []string {
+	combinations := len(firstNames) * len(lastNames)
+
+	out := make([]string, n)
+	for i := range out {
+		j := i % combinations
+		out[i] = firstNames[j%len(firstNames)] + " " + lastNames[j/len(firstNames)]
+		if i >= combinations {
+			out[i] += " " + strconv.Itoa(i/combinations+1)
+		}
+	}
+	return out
+}
+
+// buildStreets returns every street name and suffix combination.
+func buildStreets() []string {
+	out := make([]string, 0, len(streets)*len(streetSuffixes))
+	for _, suffix := range streetSuffixes {
+		for _, street := range streets {
+			out = append(out, street+" "+suffix)
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package record
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DistributionOptions configures a Distribution.
+type DistributionOptions struct {
+	// MinAge and MaxAge bound the uniformly distributed age field.
+	MinAge, MaxAge int
+
+	// NameCardinality is the number of distinct names assigned to records.
+	NameCardinality int
+
+	// Skew is the Zipf exponent used when picking names, addresses and tags -
+	// larger values concentrate more records on the most common values.
+	Skew float64
+}
+
+// Distribution generates field values resembling production data, so query
+// plans, index selectivity and compression ratios are realistic.
+//
+// Ages are uniformly distributed, dates of birth are consistent with the age
+// and spread over decades, and categorical values (names, streets, towns and
+// tags) are drawn from dictionaries with a Zipf distribution.
+//
+// Distribution is safe for concurrent use.
+type Distribution struct {
+	minAge, maxAge int
+
+	names   *dictionary
+	streets *dictionary
+	towns   *dictionary
+	tags    *dictionary
+}
+
+// NewDistribution returns a Distribution configured with opts.
+func NewDistribution(opts DistributionOptions) (*Distribution, error) {
+	if opts.MinAge < 0 || opts.MaxAge < opts.MinAge {
+		return nil, errors.New("invalid age range")
+	}
+	if opts.NameCardinality < 1 {
+		return nil, errors.New("name cardinality must be at least 1")
+	}
+	if opts.Skew <= 0 {
+		return nil, errors.New("skew must be greater than 0")
+	}
+
+	tags := make([]string, numTags)
+	for i := range tags {
+		tags[i] = "tag" + strconv.Itoa(i)
+	}
+
+	return &Distribution{
+		minAge:  opts.MinAge,
+		maxAge:  opts.MaxAge,
+		names:   newDictionary(buildNames(opts.NameCardinality), opts.Skew),
+		streets: newDictionary(buildStreets(), opts.Skew),
+		towns:   newDictionary(towns, opts.Skew),
+		tags:    newDictionary(tags, opts.Skew),
+	}, nil
+}
+
+// randomise populates the fields of p.
+func (d *Distribution) randomise(p *Person, rnd *rand.Rand) {
+	p.Name = d.names.pick(rnd)
+
+	p.Address = make([]Address, rnd.Intn(5))
+	for i := range p.Address {
+		p.Address[i].Number = uint8(rnd.Intn(200) + 1)
+		p.Address[i].Line1 = d.streets.pick(rnd)
+		p.Address[i].Line2 = d.towns.pick(rnd)
+	}
+
+	// Pick an age, and then a date of birth that results in that age today.
+	p.Age = uint32(d.minAge + rnd.Intn(d.maxAge-d.minAge+1))
+	p.DateOfBirth = time.Now().
+		AddDate(-int(p.Age), 0, 0).
+		Add(-time.Duration(rnd.Int63n(int64(365 * 24 * time.Hour)))).
+		Truncate(time.Millisecond)
+
+	p.Balance = rnd.NormFloat64()
+	p.Enabled = rnd.Intn(5) != 0
+	p.Counter = int32(rnd.Intn(1000))
+
+	p.Tags = make([]string, rnd.Intn(4))
+	for i := range p.Tags {
+		p.Tags[i] = d.tags.pick(rnd)
+	}
+}
+
+// dictionary picks values with a Zipf distribution - the first value is the
+// most likely, the second half as likely (for a skew of 1), and so on.
+type dictionary struct {
+	values []string
+	cdf    []float64
+}
+
+func newDictionary(values []string, skew float64) *dictionary {
+	cdf := make([]float64, len(values))
+
+	var sum float64
+	for i := range values {
+		sum += 1 / math.Pow(float64(i+1), skew)
+		cdf[i] = sum
+	}
+	for i := range cdf {
+		cdf[i] /= sum
+	}
+
+	return &dictionary{values: values, cdf: cdf}
+}
+
+// pick returns a random value from the dictionary.
+func (d *dictionary) pick(rnd *rand.Rand) string {
+	i := sort.SearchFloat64s(d.cdf, rnd.Float64())
+	if i == len(d.values) {
+		i--
+	}
+	return d.values[i]
+}
@@ -0,0 +1,119 @@
+package record
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDictionary_Skew(t *testing.T) {
+	d := newDictionary([]string{"a", "b", "c", "d"}, 1)
+	rnd := rand.New(rand.NewSource(42))
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[d.pick(rnd)]++
+	}
+
+	if counts["a"] <= counts["b"] || counts["b"] <= counts["d"] {
+		t.Errorf("expected decreasing frequency, got %v", counts)
+	}
+}
+
+func TestBuildNames_Distinct(t *testing.T) {
+	n := len(firstNames)*len(lastNames) + 100
+	names := buildNames(n)
+
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			t.Fatalf("duplicate name %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+}
+
+func TestDistribution_Randomise(t *testing.T) {
+	dist, err := NewDistribution(DistributionOptions{
+		MinAge:          18,
+		MaxAge:          90,
+		NameCardinality: 50,
+		Skew:            1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	p := NewPerson(nil, nil, dist)
+
+	names := map[string]struct{}{}
+	for i := 0; i < 10000; i++ {
+		p.Randomise(rnd)
+
+		if p.Age < 18 || p.Age > 90 {
+			t.Fatalf("age %d out of range", p.Age)
+		}
+
+		// The date of birth should result in the generated age.
+		age := time.Since(p.DateOfBirth).Hours() / 24 / 365.25
+		if age < float64(p.Age)-1 || age > float64(p.Age)+1 {
+			t.Fatalf("date of birth %v inconsistent with age %d", p.DateOfBirth, p.Age)
+		}
+
+		names[p.Name] = struct{}{}
+	}
+
+	if len(names) > 50 {
+		t.Errorf("got %d distinct names, want at most 50", len(names))
+	}
+}
+
+func TestNewDistribution_Invalid(t *testing.T) {
+	opts := []DistributionOptions{
+		{MinAge: 90, MaxAge: 18, NameCardinality: 1, Skew: 1},
+		{MinAge: 18, MaxAge: 90, NameCardinality: 0, Skew: 1},
+		{MinAge: 18, MaxAge: 90, NameCardinality: 1, Skew: 0},
+	}
+
+	for _, o := range opts {
+		if _, err := NewDistribution(o); err == nil {
+			t.Errorf("%+v: expected error", o)
+		}
+	}
+}
+
+func TestRandomTag_Distribution(t *testing.T) {
+	dist, err := NewDistribution(DistributionOptions{
+		MinAge:          18,
+		MaxAge:          90,
+		NameCardinality: 50,
+		Skew:            1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	skewed := NewPerson(nil, nil, dist)
+	uniform := NewPerson(nil, nil, nil)
+
+	var skewedHits, uniformHits int
+	for i := 0; i < 10000; i++ {
+		if RandomTag(skewed, rnd) == "tag0" {
+			skewedHits++
+		}
+		if RandomTag(uniform, rnd) == "tag0" {
+			uniformHits++
+		}
+	}
+
+	// With a skew of 1 over 1000 tags the first tag is picked ~13% of the
+	// time, against 0.1% uniformly.
+	if skewedHits < 1000 {
+		t.Errorf("got the most common tag %d times with a distribution, want Zipf distributed", skewedHits)
+	}
+	if uniformHits > 100 {
+		t.Errorf("got tag0 %d times without a distribution, want uniform", uniformHits)
+	}
+}
@@ -0,0 +1,107 @@
+package record
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Document is a Record with fields generated from a Schema.
+//
+// Document is encoded as a BSON or JSON object containing the ID and the
+// generated fields.
+type Document struct {
+	ID     uint64
+	Fields map[string]interface{}
+
+	schema *Schema
+}
+
+// SchemaSource returns a Document generated from Schema.
+type SchemaSource struct {
+	Schema *Schema
+}
+
+// New returns a new, empty Document.
+func (s *SchemaSource) New() Record {
+	return &Document{schema: s.Schema}
+}
+
+// GetID returns the record ID.
+func (d *Document) GetID() uint64 {
+	return d.ID
+}
+
+// SetID sets the record ID.
+func (d *Document) SetID(id uint64) {
+	d.ID = id
+}
+
+// Randomise uses rnd to populate d with fields matching the Schema - existing
+// data is overwrote.
+func (d *Document) Randomise(rnd *rand.Rand) {
+	d.Fields = d.schema.generate(rnd).(map[string]interface{})
+}
+
+// Empty returns a new, empty Document using the same Schema.
+func (d *Document) Empty() Record {
+	return &Document{schema: d.schema}
+}
+
+// GetBSON implements bson.Getter.
+func (d *Document) GetBSON() (interface{}, error) {
+	doc := make(bson.M, len(d.Fields)+1)
+	for k, v := range d.Fields {
+		doc[k] = v
+	}
+	doc["_id"] = d.ID
+	return doc, nil
+}
+
+// SetBSON implements bson.Setter.
+func (d *Document) SetBSON(raw bson.Raw) error {
+	var doc bson.M
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+
+	switch id := doc["_id"].(type) {
+	case int64:
+		d.ID = uint64(id)
+	case int:
+		d.ID = uint64(id)
+	}
+	delete(doc, "_id")
+
+	d.Fields = doc
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(d.Fields)+1)
+	for k, v := range d.Fields {
+		doc[k] = v
+	}
+	doc["id"] = d.ID
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Document) UnmarshalJSON(b []byte) error {
+	var doc struct {
+		ID uint64 `json:"id"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &d.Fields); err != nil {
+		return err
+	}
+
+	d.ID = doc.ID
+	delete(d.Fields, "id")
+	return nil
+}
@@ -0,0 +1,93 @@
+package record
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fielder is implemented by records describing their top level fields.
+type fielder interface {
+	// fields returns the names of the fields that must be present in a
+	// stored record, and those that may be present.
+	fields() (required, optional []string)
+}
+
+// CheckFields returns an error if names, the top level field names of a stored
+// record decoded into r, include a field r does not define or omit a field r
+// requires. Nested documents are not checked.
+//
+// The ID field may be named "id" (JSON) or "_id" (BSON). Records that do not
+// describe their fields are not checked.
+func CheckFields(r Record, names []string) error {
+	f, ok := r.(fielder)
+	if !ok {
+		return nil
+	}
+	required, optional := f.fields()
+
+	known := make(map[string]bool, len(required)+len(optional))
+	for _, n := range required {
+		known[n] = true
+	}
+	for _, n := range optional {
+		known[n] = true
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		if n == "_id" {
+			n = "id"
+		}
+		if !known[n] {
+			return fmt.Errorf("unknown field %q", n)
+		}
+		seen[n] = true
+	}
+
+	for _, n := range required {
+		if !seen[n] {
+			return fmt.Errorf("missing field %q", n)
+		}
+	}
+	return nil
+}
+
+// personRequired and personOptional hold the JSON names of the Person fields,
+// fields tagged omitempty being optional.
+var personRequired, personOptional = jsonFields(reflect.TypeOf(Person{}))
+
+// fields implements fielder.
+func (p *Person) fields() (required, optional []string) {
+	return personRequired, personOptional
+}
+
+// fields implements fielder, requiring the ID and the properties required by
+// the Schema.
+func (d *Document) fields() (required, optional []string) {
+	return append([]string{"id"}, d.schema.Required...), d.schema.names
+}
+
+// jsonFields returns the JSON names of the fields of the struct type t, split
+// into those always encoded and those tagged omitempty.
+func jsonFields(t reflect.Type) (required, optional []string) {
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("json")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		omitEmpty := false
+		for _, o := range opts[1:] {
+			omitEmpty = omitEmpty || o == "omitempty"
+		}
+
+		if omitEmpty {
+			optional = append(optional, opts[0])
+		} else {
+			required = append(required, opts[0])
+		}
+	}
+	return required, optional
+}
@@ -0,0 +1,143 @@
+package record
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestCheckFields(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(`{
+		"type": "object",
+		"properties": {
+			"customer": {"type": "string"},
+			"note": {"type": "string"}
+		},
+		"required": ["customer"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := (&SchemaSource{Schema: schema}).New()
+
+	person := []string{"id", "name", "addresses", "phone_number", "dob", "age", "balance", "enabled", "counter", "padding"}
+
+	tests := []struct {
+		name    string
+		rec     Record
+		names   []string
+		wantErr string
+	}{
+		{
+			name:  "person",
+			rec:   &Person{},
+			names: person,
+		},
+		{
+			name:  "person optional tags",
+			rec:   &Person{},
+			names: append([]string{"tags"}, person...),
+		},
+		{
+			name:  "person bson id",
+			rec:   &Person{},
+			names: append([]string{"_id"}, person[1:]...),
+		},
+		{
+			name:    "person unknown field",
+			rec:     &Person{},
+			names:   append([]string{"extra"}, person...),
+			wantErr: `unknown field "extra"`,
+		},
+		{
+			name:    "person missing field",
+			rec:     &Person{},
+			names:   person[:len(person)-1],
+			wantErr: `missing field "padding"`,
+		},
+		{
+			name:  "document",
+			rec:   doc,
+			names: []string{"_id", "customer"},
+		},
+		{
+			name:  "document optional property",
+			rec:   doc,
+			names: []string{"id", "customer", "note"},
+		},
+		{
+			name:    "document missing required property",
+			rec:     doc,
+			names:   []string{"id", "note"},
+			wantErr: `missing field "customer"`,
+		},
+		{
+			name:    "document unknown property",
+			rec:     doc,
+			names:   []string{"id", "customer", "price"},
+			wantErr: `unknown field "price"`,
+		},
+		{
+			name:    "document missing id",
+			rec:     doc,
+			names:   []string{"customer"},
+			wantErr: `missing field "id"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckFields(tt.rec, tt.names)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got error %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFields_Encoded(t *testing.T) {
+	p := NewPerson(FixedSize(10), nil, nil)
+	p.Randomise(rand.New(rand.NewSource(42)))
+	p.SetID(42)
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range doc {
+		names = append(names, name)
+	}
+	if err := CheckFields(p, names); err != nil {
+		t.Errorf("json: %v", err)
+	}
+
+	b, err := bson.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d bson.RawD
+	if err := bson.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	names = names[:0]
+	for _, e := range d {
+		names = append(names, e.Name)
+	}
+	if err := CheckFields(p, names); err != nil {
+		t.Errorf("bson: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package record
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// PaddingType defines the content of the padding generated by a
+// PaddingGenerator.
+type PaddingType string
+
+// Available padding types.
+const (
+	// RandomPadding is binary padding of random bytes, compressible sections
+	// are zeroed.
+	RandomPadding PaddingType = "random"
+
+	// RepeatedPadding is text padding of random letters, compressible sections
+	// repeat a short phrase.
+	RepeatedPadding PaddingType = "repeated"
+
+	// LoremPadding is text padding of random letters, compressible sections
+	// contain lorem ipsum text.
+	LoremPadding PaddingType = "lorem"
+
+	// Base64Padding is text padding of random base64 characters, compressible
+	// sections are filled with 'A'.
+	Base64Padding PaddingType = "base64"
+)
+
+var paddingTypes = []PaddingType{RandomPadding, RepeatedPadding, LoremPadding, Base64Padding}
+
+const (
+	repeatedText = "the quick brown fox jumps over the lazy dog "
+	loremText    = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do " +
+		"eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad " +
+		"minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip " +
+		"ex ea commodo consequat. Duis aute irure dolor in reprehenderit in " +
+		"voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur " +
+		"sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt " +
+		"mollit anim id est laborum. "
+
+	base64Bytes = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+// base64Expansion is the size of base64 encoded data relative to the data
+// encoded.
+const base64Expansion = 4.0 / 3.0
+
+// paddingChunk is the size of each section of padding containing both random
+// and compressible data - it is smaller than the block size of the compressors
+// used by either database, so the ratio holds for any padding size.
+const paddingChunk = 512
+
+// PaddingGenerator fills record padding with generated content that compresses
+// to approximately a configured fraction of its original size.
+//
+// Each 512 byte chunk of padding starts with random content, and is filled with
+// easily compressible content of the same type. The ratio is accurate for LZ
+// compressors without entropy coding (snappy, lz4 and pglz) - compressors with
+// entropy coding (zstd, zlib) further compress random letters in text padding.
+//
+// Base64 padding is the exception - each random base64 character carries only
+// 6 bits, so the random prefix is lengthened by base64Expansion to compress to
+// the configured ratio as the encoded binary data would. A compressibility above
+// 1/base64Expansion produces entirely random characters.
+//
+// PaddingGenerator is safe for concurrent use.
+type PaddingGenerator struct {
+	typ             PaddingType
+	compressibility float64
+}
+
+// NewPaddingGenerator returns a PaddingGenerator producing padding of typ that
+// compresses to compressibility (between 0 and 1, where 1 is incompressible) of
+// its original size.
+func NewPaddingGenerator(typ PaddingType, compressibility float64) (*PaddingGenerator, error) {
+	if compressibility < 0 || compressibility > 1 {
+		return nil, errors.New("compressibility must be between 0 and 1")
+	}
+
+	for _, t := range paddingTypes {
+		if t == typ {
+			return &PaddingGenerator{
+				typ:             typ,
+				compressibility: compressibility,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown padding type %q, valid: random repeated lorem base64", typ)
+}
+
+// text returns true if the generated padding should be stored as a string.
+func (g *PaddingGenerator) text() bool {
+	return g.typ != RandomPadding
+}
+
+// Generate returns size bytes of padding.
+func (g *PaddingGenerator) Generate(size uint64, rnd *rand.Rand) Padding {
+	p := Padding{
+		Data: make([]byte, size),
+		Text: g.text(),
+	}
+	g.fill(p.Data, rnd)
+	return p
+}
+
+// fill populates b with generated content, using rnd as a source of
+// randomness.
+func (g *PaddingGenerator) fill(b []byte, rnd *rand.Rand) {
+	for off := 0; off < len(b); off += paddingChunk {
+		end := off + paddingChunk
+		if end > len(b) {
+			end = len(b)
+		}
+
+		// The random prefix of the chunk
+		random := g.compressibility
+		if g.typ == Base64Padding {
+			random = math.Min(random*base64Expansion, 1)
+		}
+		mid := off + int(random*float64(end-off)+0.5)
+		rnd.Read(b[off:mid])
+
+		switch g.typ {
+		case RandomPadding:
+			for i := mid; i < end; i++ {
+				b[i] = 0
+			}
+
+		case RepeatedPadding, LoremPadding:
+			text := repeatedText
+			if g.typ == LoremPadding {
+				text = loremText
+			}
+			for i := off; i < mid; i++ {
+				b[i] = letterBytes[int(b[i])%len(letterBytes)]
+			}
+			for i := mid; i < end; i++ {
+				b[i] = text[i%len(text)]
+			}
+
+		case Base64Padding:
+			for i := off; i < mid; i++ {
+				b[i] = base64Bytes[b[i]&63]
+			}
+			for i := mid; i < end; i++ {
+				b[i] = 'A'
+			}
+		}
+	}
+}
+
+// Padding is the filler content of a Person, used to increase the record size.
+//
+// Binary padding is stored as BSON binary data (base64 encoded in JSON), while
+// text padding is stored as a string in both.
+type Padding struct {
+	Data []byte
+	Text bool
+}
+
+// GetBSON implements bson.Getter.
+func (p Padding) GetBSON() (interface{}, error) {
+	if p.Text {
+		return string(p.Data), nil
+	}
+	return p.Data, nil
+}
+
+// SetBSON implements bson.Setter.
+func (p *Padding) SetBSON(raw bson.Raw) error {
+	if raw.Kind == 0x02 {
+		var s string
+		if err := raw.Unmarshal(&s); err != nil {
+			return err
+		}
+		p.Data, p.Text = []byte(s), true
+		return nil
+	}
+
+	p.Text = false
+	return raw.Unmarshal(&p.Data)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Padding) MarshalJSON() ([]byte, error) {
+	if p.Text {
+		return json.Marshal(string(p.Data))
+	}
+	return json.Marshal(p.Data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// JSON does not differentiate binary and text padding - the existing value of
+// p.Text determines how data is decoded.
+func (p *Padding) UnmarshalJSON(data []byte) error {
+	if p.Text {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		p.Data = []byte(s)
+		return nil
+	}
+	return json.Unmarshal(data, &p.Data)
+}
@@ -0,0 +1,108 @@
+package record
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestNewPaddingGenerator_Invalid(t *testing.T) {
+	if _, err := NewPaddingGenerator("bananas", 1); err == nil {
+		t.Error("expected error for unknown type")
+	}
+	if _, err := NewPaddingGenerator(RandomPadding, 1.5); err == nil {
+		t.Error("expected error for compressibility > 1")
+	}
+}
+
+func TestPaddingGenerator_Compressibility(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	tests := []struct {
+		typ PaddingType
+		c   float64
+	}{
+		{typ: RandomPadding, c: 0.1},
+		{typ: RandomPadding, c: 0.5},
+		{typ: RandomPadding, c: 1},
+		{typ: Base64Padding, c: 0.1},
+		{typ: Base64Padding, c: 0.5},
+		{typ: Base64Padding, c: 0.7},
+	}
+
+	for _, tt := range tests {
+		typ, c := tt.typ, tt.c
+		g, err := NewPaddingGenerator(typ, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := make([]byte, 64*1024)
+		g.fill(b, rnd)
+
+		var buf bytes.Buffer
+		w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+		w.Write(b)
+		w.Close()
+
+		got := float64(buf.Len()) / float64(len(b))
+		if got < c-0.05 || got > c+0.05 {
+			t.Errorf("%s compressibility %v: compressed to %.3f", typ, c, got)
+		}
+	}
+}
+
+func TestPaddingGenerator_Text(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	for _, typ := range []PaddingType{RepeatedPadding, LoremPadding, Base64Padding} {
+		g, err := NewPaddingGenerator(typ, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewPerson(FixedSize(1000), g, nil)
+		p.Randomise(rnd)
+
+		if !p.Padding.Text {
+			t.Errorf("%s: padding not text", typ)
+		}
+		for _, c := range p.Padding.Data {
+			if c < ' ' || c > '~' {
+				t.Fatalf("%s: non-printable padding byte %q", typ, c)
+			}
+		}
+
+		// Both encodings should store the padding as a string, and decode
+		// into an empty record
+		b, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := p.Empty().(*Person)
+		if err := json.Unmarshal(b, got); err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if !bytes.Equal(got.Padding.Data, p.Padding.Data) {
+			t.Errorf("%s: json padding mismatch", typ)
+		}
+
+		b, err = bson.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw struct {
+			Padding interface{} `bson:"padding"`
+		}
+		if err := bson.Unmarshal(b, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := raw.Padding.(string); !ok {
+			t.Errorf("%s: bson padding is %T, want string", typ, raw.Padding)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package record
 
 import (
 	"math/rand"
+	"strconv"
 	"time"
 )
 
@@ -18,7 +19,31 @@ type Person struct {
 	Balance     float64   `bson:"balance"       json:"balance"`
 	Enabled     bool      `bson:"enabled"       json:"enabled"`
 	Counter     int32     `bson:"counter"       json:"counter"`
-	Padding     []byte    `bson:"padding"       json:"padding"`
+	Tags        []string  `bson:"tags,omitempty" json:"tags,omitempty"`
+	Padding     Padding   `bson:"padding"       json:"padding"`
+
+	size    Size
+	padding *PaddingGenerator
+	dist    *Distribution
+}
+
+// NewPerson returns a Person with padding sized by size, using padding to
+// generate the padding content and dist to generate field values in Randomise.
+//
+// If size is nil, the Person has no padding. If padding is nil, the padding is
+// filled with random bytes. If dist is nil, Randomise populates fields with
+// uniformly random values.
+func NewPerson(size Size, padding *PaddingGenerator, dist *Distribution) *Person {
+	if size == nil {
+		size = FixedSize(0)
+	}
+
+	return &Person{
+		Padding: Padding{Text: padding != nil && padding.text()},
+		size:    size,
+		padding: padding,
+		dist:    dist,
+	}
 }
 
 // Address defines a sub-document within Person.
@@ -33,6 +58,42 @@ type Address struct {
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
+// numTags is the number of distinct values RandomTag returns.
+const numTags = 1000
+
+// RandomTag returns one of the tag values assigned to records like r by
+// Randomise, drawn from the same distribution - the Zipf distribution of it's
+// Distribution if r is a Person created with one, otherwise uniformly.
+func RandomTag(r Record, rnd *rand.Rand) string {
+	if p, ok := r.(*Person); ok && p.dist != nil {
+		return p.dist.tags.pick(rnd)
+	}
+	return "tag" + strconv.Itoa(rnd.Intn(numTags))
+}
+
+// phoneMultiplier scatters sequential IDs across the phone number space - it
+// shares no factors with 900000000, so each ID below 900000000 maps to a
+// distinct phone number.
+const phoneMultiplier = 282475249
+
+// PhoneNumber returns the phone number assigned to the record with id.
+//
+// Phone numbers are derived from the record ID, rather than randomly
+// generated, so lookups by phone number can find existing records without
+// remembering every value written.
+func PhoneNumber(id uint64) string {
+	return "07" + strconv.FormatUint(100000000+(id*phoneMultiplier)%900000000, 10)
+}
+
+// RandomAddress returns an Address with a random number, street and town.
+func RandomAddress(rnd *rand.Rand) Address {
+	return Address{
+		Number: uint8(rnd.Intn(200) + 1),
+		Line1:  streets[rnd.Intn(len(streets))] + " " + streetSuffixes[rnd.Intn(len(streetSuffixes))],
+		Line2:  towns[rnd.Intn(len(towns))],
+	}
+}
+
 func (p *Person) randStringBytesRmndr(rnd *rand.Rand, n int) string {
 	x := rnd.Int63()
 	b := make([]byte, int(x)%n)
@@ -44,8 +105,27 @@ func (p *Person) randStringBytesRmndr(rnd *rand.Rand, n int) string {
 }
 
 // Randomise uses rnd to populate p - existing data is overwrote.
+//
+// If p was created with a Distribution, field values are drawn from it.
 func (p *Person) Randomise(rnd *rand.Rand) {
-	rnd.Read(p.Padding)
+	// Reuse the padding buffer if it is large enough
+	size := p.size.Next(rnd)
+	if p.Padding.Data == nil || uint64(cap(p.Padding.Data)) < size {
+		p.Padding.Data = make([]byte, size)
+	}
+	p.Padding.Data = p.Padding.Data[:size]
+
+	if p.padding != nil {
+		p.padding.fill(p.Padding.Data, rnd)
+	} else {
+		rnd.Read(p.Padding.Data)
+	}
+
+	if p.dist != nil {
+		p.dist.randomise(p, rnd)
+		return
+	}
+
 	p.Name = p.randStringBytesRmndr(rnd, 50)
 
 	n := rnd.Uint64()
@@ -56,9 +136,14 @@ func (p *Person) Randomise(rnd *rand.Rand) {
 		p.Address[i].Line2 = p.randStringBytesRmndr(rnd, 30)
 	}
 
-	p.PhoneNumber = p.randStringBytesRmndr(rnd, 30)
 	p.DateOfBirth = time.Now()
 	p.Age = uint32(n)
 	p.Balance = rnd.NormFloat64()
 	p.Counter = int32(n)
+	p.Enabled = rnd.Intn(2) == 0
+
+	p.Tags = make([]string, rnd.Intn(4))
+	for i := range p.Tags {
+		p.Tags[i] = RandomTag(p, rnd)
+	}
 }
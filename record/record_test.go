@@ -0,0 +1,43 @@
+package record
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPhoneNumber(t *testing.T) {
+	seen := map[string]uint64{}
+	for id := uint64(1); id <= 100000; id++ {
+		phone := PhoneNumber(id)
+		if len(phone) != 11 {
+			t.Fatalf("id %d: got phone number %q, want 11 digits", id, phone)
+		}
+		if other, ok := seen[phone]; ok {
+			t.Fatalf("ids %d and %d share phone number %q", id, other, phone)
+		}
+		seen[phone] = id
+	}
+
+	p := &Person{}
+	p.SetID(42)
+	if p.PhoneNumber != PhoneNumber(42) {
+		t.Errorf("SetID did not set the phone number")
+	}
+}
+
+func TestPerson_RandomiseEnabled(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	p := NewPerson(nil, nil, nil)
+
+	var enabled int
+	for i := 0; i < 1000; i++ {
+		p.Randomise(rnd)
+		if p.Enabled {
+			enabled++
+		}
+	}
+
+	if enabled == 0 || enabled == 1000 {
+		t.Errorf("got %d of 1000 records enabled, want a mix", enabled)
+	}
+}
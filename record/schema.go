@@ -0,0 +1,259 @@
+package record
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Schema describes the shape of a Document using a subset of JSON Schema.
+//
+// The top level schema must be an object. The following types and keywords are
+// supported:
+//
+//	object:  properties, required
+//	array:   items, minItems, maxItems (defaults 0 - 5)
+//	string:  minLength, maxLength (defaults 1 - 20), enum, format ("date-time")
+//	integer: minimum, maximum (defaults 0 - 1000), enum
+//	number:  minimum, maximum (defaults 0 - 1), enum
+//	boolean
+//
+// If only one bound is given, the other is derived from it (see intRange and
+// floatRange).
+//
+// As in JSON Schema, properties not listed in required are optional - they are
+// present in approximately half of the generated documents.
+//
+// Example:
+//
+//	{
+//		"type": "object",
+//		"properties": {
+//			"customer": {"type": "string", "minLength": 5, "maxLength": 30},
+//			"placed":   {"type": "string", "format": "date-time"},
+//			"items": {
+//				"type": "array",
+//				"minItems": 1,
+//				"maxItems": 10,
+//				"items": {
+//					"type": "object",
+//					"properties": {
+//						"sku":      {"type": "string", "enum": ["A1", "B2", "C3"]},
+//						"quantity": {"type": "integer", "minimum": 1, "maximum": 5},
+//						"price":    {"type": "number", "minimum": 0.5, "maximum": 100}
+//					},
+//					"required": ["sku", "quantity", "price"]
+//				}
+//			},
+//			"note": {"type": "string", "maxLength": 200}
+//		},
+//		"required": ["customer", "placed", "items"]
+//	}
+type Schema struct {
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Properties map[string]*Schema `json:"properties"`
+	Required   []string           `json:"required"`
+	Items      *Schema            `json:"items"`
+	MinItems   *int               `json:"minItems"`
+	MaxItems   *int               `json:"maxItems"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	Enum       []interface{}      `json:"enum"`
+
+	// names holds the property names in a stable order, and required the set
+	// of required property names.
+	names    []string
+	required map[string]bool
+}
+
+// ParseSchema decodes and validates a Schema from r.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	if s.Type != "object" {
+		return nil, errors.New("top level schema type must be object")
+	}
+	if _, ok := s.Properties["id"]; ok {
+		return nil, errors.New("property name id is reserved for the record ID")
+	}
+	if _, ok := s.Properties["_id"]; ok {
+		return nil, errors.New("property name _id is reserved for the record ID")
+	}
+
+	if err := s.init("$"); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// init validates s and all child schemas, path is used in error messages.
+func (s *Schema) init(path string) error {
+	switch s.Type {
+	case "object":
+		s.required = map[string]bool{}
+		for _, name := range s.Required {
+			if _, ok := s.Properties[name]; !ok {
+				return fmt.Errorf("%s: required property %q not defined", path, name)
+			}
+			s.required[name] = true
+		}
+
+		for name, child := range s.Properties {
+			if child == nil {
+				return fmt.Errorf("%s.%s: missing schema", path, name)
+			}
+			if err := child.init(path + "." + name); err != nil {
+				return err
+			}
+			s.names = append(s.names, name)
+		}
+		sort.Strings(s.names)
+
+	case "array":
+		if s.Items == nil {
+			return fmt.Errorf("%s: array has no items schema", path)
+		}
+		if lo, hi := intRange(s.MinItems, s.MaxItems, 0, 5); lo < 0 || hi < lo {
+			return fmt.Errorf("%s: invalid minItems/maxItems", path)
+		}
+		return s.Items.init(path + "[]")
+
+	case "string":
+		if lo, hi := intRange(s.MinLength, s.MaxLength, 1, 20); lo < 0 || hi < lo {
+			return fmt.Errorf("%s: invalid minLength/maxLength", path)
+		}
+		switch s.Format {
+		case "", "date-time":
+		default:
+			return fmt.Errorf("%s: unsupported string format %q", path, s.Format)
+		}
+
+	case "integer", "number":
+		if s.Minimum != nil && s.Maximum != nil && *s.Maximum < *s.Minimum {
+			return fmt.Errorf("%s: maximum is less than minimum", path)
+		}
+
+	case "boolean":
+
+	default:
+		return fmt.Errorf("%s: unsupported type %q", path, s.Type)
+	}
+
+	return nil
+}
+
+// generate returns a random value matching s.
+//
+// Objects are returned as a map[string]interface{}, and date-time strings as a
+// time.Time so they are stored as a native date type in MongoDB.
+func (s *Schema) generate(rnd *rand.Rand) interface{} {
+	if len(s.Enum) > 0 {
+		return s.Enum[rnd.Intn(len(s.Enum))]
+	}
+
+	switch s.Type {
+	case "object":
+		obj := make(map[string]interface{}, len(s.names))
+		for _, name := range s.names {
+			if !s.required[name] && rnd.Intn(2) == 0 {
+				continue
+			}
+			obj[name] = s.Properties[name].generate(rnd)
+		}
+		return obj
+
+	case "array":
+		lo, hi := intRange(s.MinItems, s.MaxItems, 0, 5)
+		arr := make([]interface{}, lo+rnd.Intn(hi-lo+1))
+		for i := range arr {
+			arr[i] = s.Items.generate(rnd)
+		}
+		return arr
+
+	case "string":
+		if s.Format == "date-time" {
+			// Within the last 10 years, with the millisecond precision of a
+			// BSON date.
+			age := time.Duration(rnd.Int63n(int64(10 * 365 * 24 * time.Hour)))
+			return time.Now().Add(-age).Truncate(time.Millisecond).UTC()
+		}
+
+		lo, hi := intRange(s.MinLength, s.MaxLength, 1, 20)
+		b := make([]byte, lo+rnd.Intn(hi-lo+1))
+		for i := range b {
+			b[i] = letterBytes[rnd.Intn(len(letterBytes))]
+		}
+		return string(b)
+
+	case "integer":
+		lo, hi := floatRange(s.Minimum, s.Maximum, 0, 1000)
+		return int64(lo) + rnd.Int63n(int64(hi)-int64(lo)+1)
+
+	case "number":
+		lo, hi := floatRange(s.Minimum, s.Maximum, 0, 1)
+		return lo + rnd.Float64()*(hi-lo)
+
+	case "boolean":
+		return rnd.Intn(2) == 0
+	}
+
+	return nil
+}
+
+// intRange returns the bounds min and max, using the defaults defLo and defHi
+// for a missing bound.
+//
+// If only min is given and it exceeds defHi, the upper bound is derived from it
+// to keep the default width. If only max is given and it is less than defLo,
+// the lower bound is max.
+func intRange(min, max *int, defLo, defHi int) (int, int) {
+	lo, hi := intOr(min, defLo), intOr(max, defHi)
+	switch {
+	case max == nil && hi < lo:
+		hi = lo + defHi - defLo
+	case min == nil && lo > hi:
+		lo = hi
+	}
+	return lo, hi
+}
+
+// floatRange returns the bounds min and max, using the defaults defLo and defHi
+// for a missing bound.
+//
+// If only one bound is given and it falls outside of the default range, the
+// other bound is derived from it to keep the default width.
+func floatRange(min, max *float64, defLo, defHi float64) (float64, float64) {
+	lo, hi := floatOr(min, defLo), floatOr(max, defHi)
+	switch {
+	case max == nil && hi < lo:
+		hi = lo + defHi - defLo
+	case min == nil && lo > hi:
+		lo = hi - (defHi - defLo)
+	}
+	return lo, hi
+}
+
+func intOr(v *int, def int) int {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+func floatOr(v *float64, def float64) float64 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
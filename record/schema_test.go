@@ -0,0 +1,192 @@
+package record
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"customer": {"type": "string", "minLength": 5, "maxLength": 30},
+		"placed":   {"type": "string", "format": "date-time"},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"maxItems": 10,
+			"items": {
+				"type": "object",
+				"properties": {
+					"sku":      {"type": "string", "enum": ["A1", "B2", "C3"]},
+					"quantity": {"type": "integer", "minimum": 1, "maximum": 5},
+					"price":    {"type": "number", "minimum": 0.5, "maximum": 100}
+				},
+				"required": ["sku", "quantity", "price"]
+			}
+		},
+		"note": {"type": "string", "maxLength": 200}
+	},
+	"required": ["customer", "placed", "items"]
+}`
+
+func TestParseSchema_Invalid(t *testing.T) {
+	schemas := []string{
+		`{"type": "array", "items": {"type": "string"}}`,
+		`{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "uuid"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "array"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string", "minLength": 5, "maxLength": 1}}}`,
+		`{"type": "object", "properties": {}, "required": ["a"]}`,
+	}
+
+	for _, s := range schemas {
+		if _, err := ParseSchema(strings.NewReader(s)); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestSchema_Generate(t *testing.T) {
+	s, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	doc := (&SchemaSource{Schema: s}).New()
+
+	var notes int
+	for i := 0; i < 1000; i++ {
+		doc.Randomise(rnd)
+		fields := doc.(*Document).Fields
+
+		if c := fields["customer"].(string); len(c) < 5 || len(c) > 30 {
+			t.Fatalf("customer %q length out of range", c)
+		}
+		if _, ok := fields["placed"].(time.Time); !ok {
+			t.Fatalf("placed is %T, want time.Time", fields["placed"])
+		}
+
+		items := fields["items"].([]interface{})
+		if len(items) < 1 || len(items) > 10 {
+			t.Fatalf("got %d items, want 1 - 10", len(items))
+		}
+		for _, item := range items {
+			item := item.(map[string]interface{})
+			if q := item["quantity"].(int64); q < 1 || q > 5 {
+				t.Fatalf("quantity %d out of range", q)
+			}
+			if len(item) != 3 {
+				t.Fatalf("item missing required fields: %v", item)
+			}
+		}
+
+		if _, ok := fields["note"]; ok {
+			notes++
+		}
+	}
+
+	// The optional note should be present roughly half the time
+	if notes < 400 || notes > 600 {
+		t.Errorf("optional field present %d/1000 times", notes)
+	}
+}
+
+func TestSchema_GenerateOneBound(t *testing.T) {
+	tests := []struct {
+		schema string
+		lo, hi float64
+	}{
+		{`{"type": "integer", "minimum": 2000}`, 2000, 3000},
+		{`{"type": "integer", "maximum": -1}`, -1001, -1},
+		{`{"type": "number", "minimum": 5}`, 5, 6},
+		{`{"type": "number", "maximum": -5}`, -6, -5},
+		{`{"type": "string", "minLength": 30}`, 30, 49},
+		{`{"type": "string", "maxLength": 0}`, 0, 0},
+		{`{"type": "array", "minItems": 10, "items": {"type": "boolean"}}`, 10, 15},
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for _, tt := range tests {
+		s, err := ParseSchema(strings.NewReader(`{"type": "object", "properties": {"a": ` + tt.schema + `}, "required": ["a"]}`))
+		if err != nil {
+			t.Errorf("%s: %v", tt.schema, err)
+			continue
+		}
+
+		for i := 0; i < 1000; i++ {
+			var got float64
+			switch v := s.generate(rnd).(map[string]interface{})["a"].(type) {
+			case int64:
+				got = float64(v)
+			case float64:
+				got = v
+			case string:
+				got = float64(len(v))
+			case []interface{}:
+				got = float64(len(v))
+			}
+
+			if got < tt.lo || got > tt.hi {
+				t.Errorf("%s: got %v, want %v - %v", tt.schema, got, tt.lo, tt.hi)
+				break
+			}
+		}
+	}
+}
+
+func TestDocument_RoundTrip(t *testing.T) {
+	s, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := (&SchemaSource{Schema: s}).New()
+	doc.Randomise(rand.New(rand.NewSource(42)))
+	doc.SetID(42)
+
+	// JSON
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := doc.Empty().(*Document)
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 42 {
+		t.Errorf("json: got ID %d, want 42", got.ID)
+	}
+	if _, ok := got.Fields["id"]; ok {
+		t.Error("json: id included in fields")
+	}
+	if len(got.Fields) != len(doc.(*Document).Fields) {
+		t.Errorf("json: got %d fields, want %d", len(got.Fields), len(doc.(*Document).Fields))
+	}
+
+	// BSON
+	b, err = bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got = doc.Empty().(*Document)
+	if err := bson.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 42 {
+		t.Errorf("bson: got ID %d, want 42", got.ID)
+	}
+	if _, ok := got.Fields["_id"]; ok {
+		t.Error("bson: _id included in fields")
+	}
+	if len(got.Fields) != len(doc.(*Document).Fields) {
+		t.Errorf("bson: got %d fields, want %d", len(got.Fields), len(doc.(*Document).Fields))
+	}
+}
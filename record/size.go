@@ -0,0 +1,227 @@
+package record
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
+)
+
+// Size returns the size of the padding in each record.
+//
+// Implementations must be safe for concurrent use.
+type Size interface {
+	Next(rnd *rand.Rand) uint64
+}
+
+// FixedSize returns the same size for every record.
+type FixedSize uint64
+
+// Next returns s.
+func (s FixedSize) Next(_ *rand.Rand) uint64 {
+	return uint64(s)
+}
+
+// UniformSize returns sizes uniformly distributed between Min and Max
+// (inclusive).
+type UniformSize struct {
+	Min, Max uint64
+}
+
+// Next returns a random size between s.Min and s.Max.
+func (s UniformSize) Next(rnd *rand.Rand) uint64 {
+	return s.Min + uint64(rnd.Int63n(int64(s.Max-s.Min+1)))
+}
+
+// NormalSize returns normally distributed sizes, truncated at 0.
+type NormalSize struct {
+	Mean, StdDev float64
+}
+
+// Next returns a random size from the normal distribution.
+func (s NormalSize) Next(rnd *rand.Rand) uint64 {
+	return truncate(rnd.NormFloat64()*s.StdDev + s.Mean)
+}
+
+// LogNormalSize returns log-normally distributed sizes - most records are close
+// to Median, with a long tail of larger records controlled by Sigma.
+type LogNormalSize struct {
+	Median, Sigma float64
+}
+
+// Next returns a random size from the log-normal distribution.
+func (s LogNormalSize) Next(rnd *rand.Rand) uint64 {
+	return truncate(s.Median * math.Exp(rnd.NormFloat64()*s.Sigma))
+}
+
+// EmpiricalSize returns sizes following a histogram, such as one taken from a
+// production dataset.
+type EmpiricalSize struct {
+	// bounds[i] is the inclusive upper bound of bucket i, the exclusive lower
+	// bound is the upper bound of the previous bucket (the first bucket starts
+	// at 0).
+	bounds []uint64
+	cdf    []float64
+}
+
+// Next returns a random size from a bucket picked with a probability
+// proportional to it's count.
+func (s *EmpiricalSize) Next(rnd *rand.Rand) uint64 {
+	i := sort.SearchFloat64s(s.cdf, rnd.Float64())
+	if i == len(s.bounds) {
+		i--
+	}
+
+	if i == 0 {
+		return uint64(rnd.Int63n(int64(s.bounds[0] + 1)))
+	}
+
+	lo := s.bounds[i-1]
+	return lo + 1 + uint64(rnd.Int63n(int64(s.bounds[i]-lo)))
+}
+
+// ReadHistogram parses a size histogram from r.
+//
+// Each line contains the upper bound of a bucket (ascending, valid suffixes:
+// kb, mb) and the number of records in it, separated by a comma. Blank lines
+// and lines beginning with # are ignored.
+func ReadHistogram(r io.Reader) (*EmpiricalSize, error) {
+	s := &EmpiricalSize{}
+
+	var total float64
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected size,count", line)
+		}
+
+		bound, err := parseBytes(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if n := len(s.bounds); n > 0 && bound <= s.bounds[n-1] {
+			return nil, fmt.Errorf("line %d: sizes must be ascending", line)
+		}
+
+		count, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("line %d: invalid count %q", line, fields[1])
+		}
+
+		total += count
+		s.bounds = append(s.bounds, bound)
+		s.cdf = append(s.cdf, total)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if total == 0 {
+		return nil, errors.New("histogram is empty")
+	}
+	for i := range s.cdf {
+		s.cdf[i] /= total
+	}
+
+	return s, nil
+}
+
+// ParseSize returns the Size described by spec, one of:
+//
+//	<size>                   every record has the same size (e.g. "1kb")
+//	uniform:<min>:<max>      uniformly distributed sizes
+//	normal:<mean>:<stddev>   normally distributed sizes
+//	lognormal:<median>:<σ>   log-normally distributed sizes
+//	histogram:<file>         sizes following the histogram file (see ReadHistogram)
+//
+// Sizes accept the kb and mb suffixes.
+func ParseSize(spec string) (Size, error) {
+	parts := strings.Split(spec, ":")
+
+	switch parts[0] {
+	case "histogram":
+		if len(parts) < 2 {
+			return nil, errors.New("histogram requires a file path")
+		}
+		f, err := os.Open(strings.Join(parts[1:], ":"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return ReadHistogram(f)
+
+	case "uniform", "normal", "lognormal":
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%s requires two parameters", parts[0])
+		}
+
+	default:
+		if len(parts) != 1 {
+			return nil, fmt.Errorf("unknown size distribution %q, valid: uniform normal lognormal histogram", parts[0])
+		}
+		n, err := parseBytes(spec)
+		return FixedSize(n), err
+	}
+
+	a, err := parseBytes(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	switch parts[0] {
+	case "uniform":
+		b, err := parseBytes(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		if b < a {
+			return nil, errors.New("uniform max is less than min")
+		}
+		return UniformSize{Min: a, Max: b}, nil
+
+	case "normal":
+		b, err := parseBytes(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		return NormalSize{Mean: float64(a), StdDev: float64(b)}, nil
+	}
+
+	sigma, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || sigma < 0 {
+		return nil, fmt.Errorf("invalid lognormal sigma %q", parts[2])
+	}
+	return LogNormalSize{Median: float64(a), Sigma: sigma}, nil
+}
+
+// parseBytes parses a size with an optional kb or mb suffix.
+func parseBytes(s string) (uint64, error) {
+	var b datasize.ByteSize
+	if err := b.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return b.Bytes(), nil
+}
+
+// truncate returns f as a uint64, or 0 if f is negative.
+func truncate(f float64) uint64 {
+	if f < 0 {
+		return 0
+	}
+	return uint64(f + 0.5)
+}
@@ -0,0 +1,96 @@
+package record
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Size
+	}{
+		{"0", FixedSize(0)},
+		{"1kb", FixedSize(1024)},
+		{"uniform:1kb:2kb", UniformSize{Min: 1024, Max: 2048}},
+		{"normal:4kb:1kb", NormalSize{Mean: 4096, StdDev: 1024}},
+		{"lognormal:4kb:0.5", LogNormalSize{Median: 4096, Sigma: 0.5}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %#v, want %#v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"bananas", "uniform:2kb:1kb", "normal:1kb", "lognormal:1kb:x", "zipf:1:2"} {
+		if _, err := ParseSize(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestSize_Mean(t *testing.T) {
+	tests := []struct {
+		name string
+		size Size
+		want float64
+	}{
+		{"uniform", UniformSize{Min: 1000, Max: 3000}, 2000},
+		{"normal", NormalSize{Mean: 2000, StdDev: 200}, 2000},
+		{"lognormal", LogNormalSize{Median: 2000, Sigma: 0}, 2000},
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for _, tt := range tests {
+		var sum float64
+		for i := 0; i < 10000; i++ {
+			sum += float64(tt.size.Next(rnd))
+		}
+		if mean := sum / 10000; mean < tt.want*0.98 || mean > tt.want*1.02 {
+			t.Errorf("%s: got mean %.1f, want %.1f", tt.name, mean, tt.want)
+		}
+	}
+}
+
+func TestReadHistogram(t *testing.T) {
+	h, err := ReadHistogram(strings.NewReader(`
+# size,count
+1kb,3
+4kb,0
+10kb,1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	var small int
+	for i := 0; i < 10000; i++ {
+		n := h.Next(rnd)
+		switch {
+		case n <= 1024:
+			small++
+		case n <= 4096:
+			t.Fatalf("got %d from an empty bucket", n)
+		case n > 10240:
+			t.Fatalf("got %d, larger than the last bucket", n)
+		}
+	}
+
+	if small < 7300 || small > 7700 {
+		t.Errorf("got %d/10000 from the first bucket, want ~7500", small)
+	}
+
+	for _, in := range []string{"", "1kb", "2kb,1\n1kb,1", "1kb,-1"} {
+		if _, err := ReadHistogram(strings.NewReader(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
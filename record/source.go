@@ -0,0 +1,59 @@
+package record
+
+import "math/rand"
+
+// Record defines a document stored in the database.
+//
+// Implementations must encode the ID as "_id" in BSON, and "id" in JSON.
+type Record interface {
+	GetID() uint64
+	SetID(id uint64)
+
+	// Randomise uses rnd to populate the record - existing data is overwrote.
+	Randomise(rnd *rand.Rand)
+
+	// Empty returns a new, empty Record of the same type, suitable for decoding
+	// a stored record into.
+	Empty() Record
+}
+
+// Source returns a Record for each worker.
+//
+// Implementations must be safe for concurrent use, however the returned Record
+// is not.
+type Source interface {
+	New() Record
+}
+
+// PersonSource returns a Person with padding sized by PaddingSize, using Padding
+// to generate the padding content and Distribution to generate field values.
+//
+// If PaddingSize is nil, records have no padding. If Padding is nil, the padding
+// is random bytes. If Distribution is nil, field values are uniformly random.
+type PersonSource struct {
+	PaddingSize  Size
+	Padding      *PaddingGenerator
+	Distribution *Distribution
+}
+
+// New returns a new Person.
+func (s *PersonSource) New() Record {
+	return NewPerson(s.PaddingSize, s.Padding, s.Distribution)
+}
+
+// GetID returns the record ID.
+func (p *Person) GetID() uint64 {
+	return p.ID
+}
+
+// SetID sets the record ID, and the phone number derived from it (see
+// PhoneNumber) - any phone number set by Randomise is replaced.
+func (p *Person) SetID(id uint64) {
+	p.ID = id
+	p.PhoneNumber = PhoneNumber(id)
+}
+
+// Empty returns a new, empty Person decoding padding of the same type as p.
+func (p *Person) Empty() Record {
+	return &Person{Padding: Padding{Text: p.Padding.Text}}
+}
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Index identifies an optional secondary index created by a provider's Setup
+// method.
+//
+// An index on the record ID is always created.
+type Index string
+
+const (
+	// AgeIndex is a partial index on the age field covering records where age is
+	// greater than 45 and less than 75, matching the read-range workload.
+	AgeIndex Index = "age"
+
+	// GINIndex is a GIN index over the whole document in Postgres, using the
+	// operator class set in Options.GINOpClass.
+	//
+	// MongoDB has no direct equivalent, so a wildcard index is created instead.
+	GINIndex Index = "gin"
+
+	// TagsIndex is a GIN index on the tags array in Postgres, using the
+	// operator class set in Options.GINOpClass, and a multikey index in
+	// MongoDB.
+	TagsIndex Index = "tags"
+
+	// AddressesIndex is a GIN index on the addresses array in Postgres, using
+	// the operator class set in Options.GINOpClass, and a compound multikey
+	// index on the address line and number in MongoDB.
+	AddressesIndex Index = "addresses"
+
+	// PhoneIndex is an index on the phone_number field - an expression index
+	// on data->>'phone_number' in Postgres.
+	PhoneIndex Index = "phone"
+
+	// PhoneColumnIndex adds a generated phone_number column to the Postgres
+	// table (requires 12+) with a BTREE index, allowing index-only scans that
+	// an expression index cannot provide.
+	//
+	// MongoDB needs no generated field to cover a query, so the PhoneIndex is
+	// created instead.
+	PhoneColumnIndex Index = "phone-column"
+
+	// BalanceIndex is an index on the balance field, supporting the sort
+	// workload.
+	BalanceIndex Index = "balance"
+
+	// DOBIndex is an index on the dob field, supporting the sort workload.
+	DOBIndex Index = "dob"
+)
+
+// indexes lists all the valid Index values.
+var indexes = []Index{AgeIndex, GINIndex, TagsIndex, AddressesIndex, PhoneIndex, PhoneColumnIndex, BalanceIndex, DOBIndex}
+
+// Options describes the table/collection and indexes to create.
+type Options struct {
+	// Indexes to create in addition to the ID index.
+	Indexes []Index
+
+	// GINOpClass is the Postgres operator class used for the GINIndex,
+	// TagsIndex and AddressesIndex, either "jsonb_ops" or "jsonb_path_ops".
+	GINOpClass string
+
+	// FillFactor sets the Postgres table fillfactor, 0 uses the server default.
+	//
+	// Ignored by MongoDB.
+	FillFactor int
+
+	// Compression sets the WiredTiger block compressor in MongoDB, or the
+	// column compression method in Postgres (requires 14+). An empty string
+	// uses the server default.
+	Compression string
+
+	// PartitionInterval partitions the Postgres events table used by the
+	// time-series workload by event time, with one partition per interval. 0
+	// disables partitioning.
+	//
+	// Ignored by MongoDB.
+	PartitionInterval time.Duration
+
+	// EventTTL creates a TTL index expiring events in the MongoDB events
+	// collection once they are older than EventTTL. 0 disables expiry.
+	//
+	// Ignored by Postgres.
+	EventTTL time.Duration
+}
+
+// Has returns true if idx should be created.
+func (o *Options) Has(idx Index) bool {
+	for _, i := range o.Indexes {
+		if i == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIndexes parses a comma separated list of index names.
+//
+// An empty string returns no indexes.
+func ParseIndexes(s string) ([]Index, error) {
+	var out []Index
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		idx, err := parseIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, idx)
+	}
+	return out, nil
+}
+
+func parseIndex(name string) (Index, error) {
+	for _, idx := range indexes {
+		if string(idx) == strings.ToLower(name) {
+			return idx, nil
+		}
+	}
+	return "", fmt.Errorf("unknown index %q", name)
+}
@@ -10,8 +10,8 @@
 # results to the remote.
 # 
 # By default an index is created on the ID field and a partial index covers
-# records with an "age" field covering 40 < X < 75. To change, edit
-# reset_postgres or reset_mongo respectively.
+# records with an "age" field covering 45 < X < 75. To change, set INDEXES (see
+# the setup command in the tool --help output).
 
 set -EC
 
@@ -45,6 +45,7 @@ PG_SET_ARC=${PG_SET_ARC:-""}
 BENCH_TOOL=${BENCH_TOOL:-"./mpjbt"}
 WORKERS=${WORKERS:-30}
 PADDING=${PADDING:-""}
+INDEXES=${INDEXES:-"age"}
 
 # Number of operations - updates seem slow so let's do less
 OPS_COUNT=${OPS_COUNT:-100000}
@@ -109,22 +110,23 @@ error_cleanup() {
 trap 'error_cleanup' ERR
 trap 'previous_command=$this_command; this_command=$BASH_COMMAND' DEBUG
 
-# reset_mongo drops the test collection
+# reset_mongo drops the test collection and then recreates it.
 reset_mongo() {
-	$MONGO_SHELL --eval "db.$TABLE_NAME.drop()"
-	$MONGO_SHELL --eval "db.$TABLE_NAME.createIndex( { 'age': 1 },{ partialFilterExpression: { 'age': { '\$gt': 45,'\$lt': 75} } })"
-	return $?
+	reset_db $MONGO_CONN
 }
 
 # reset_postgres drops the test table and indexes and then recreates them.
 reset_postgres() {
-	$PG_SHELL -c "DROP TABLE $TABLE_NAME;"  || true
-	$PG_SHELL -c "DROP INDEX idx_json_data;"  || true
-	$PG_SHELL -c "DROP INDEX idx_json_data_age;"  || true
+	reset_db $PG_CONN
+}
 
-	$PG_SHELL -c "CREATE TABLE $TABLE_NAME (data jsonb);"
-	$PG_SHELL -c "CREATE INDEX idx_json_data ON $TABLE_NAME USING BTREE ((data->'id'));"
-	$PG_SHELL -c "CREATE INDEX idx_json_data_age ON $TABLE_NAME USING BTREE ((data->'age')) where data->'age' > '45' and data->'age' < '75';"
+# reset_db uses the benchmark tool to drop and recreate the table/collection and
+# indexes for the given connection string.
+# 
+# reset_db <connection_string>
+reset_db() {
+	$BENCH_TOOL -connect=$1 -table=$TABLE_NAME teardown
+	$BENCH_TOOL -connect=$1 -table=$TABLE_NAME -indexes=$INDEXES setup
 }
 
 TEST_NUMBER=0
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// intervalLatency accumulates the latency of successful calls until taken.
+type intervalLatency struct {
+	calls uint64 // atomic
+	nanos uint64 // atomic
+}
+
+// timed returns a CountFunc calling f, adding the latency of each successful
+// call to l.
+func (l *intervalLatency) timed(f plan.CountFunc) plan.CountFunc {
+	return func(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+		start := time.Now()
+		n, ok := f(data, id, rnd)
+		if ok {
+			atomic.AddUint64(&l.nanos, uint64(time.Since(start)))
+			atomic.AddUint64(&l.calls, 1)
+		}
+		return n, ok
+	}
+}
+
+// take returns the number of calls and their average latency since the last
+// call to take.
+func (l *intervalLatency) take() (uint64, time.Duration) {
+	calls := atomic.SwapUint64(&l.calls, 0)
+	nanos := atomic.SwapUint64(&l.nanos, 0)
+	if calls == 0 {
+		return 0, 0
+	}
+	return calls, time.Duration(nanos / calls)
+}
+
+// seriesSampler samples the size of the events table/collection, the number of
+// events appended and the latency of the window queries every interval of a
+// timeseries run, showing how they change as the data grows.
+type seriesSampler struct {
+	db       dbProvider
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+
+	appends        uint64 // atomic
+	window, rollup intervalLatency
+
+	// Only accessed by the sampling goroutine until stopped.
+	begin   time.Time
+	samples [][]string
+}
+
+// newSeriesSampler returns a seriesSampler for db, sampling every interval once
+// started.
+func newSeriesSampler(db dbProvider, interval time.Duration) *seriesSampler {
+	return &seriesSampler{
+		db:       db,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// appended returns a DoFunc calling f, counting the successful calls.
+func (s *seriesSampler) appended(f plan.DoFunc) plan.DoFunc {
+	return func(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+		ok := f(data, id, rnd)
+		if ok {
+			atomic.AddUint64(&s.appends, 1)
+		}
+		return ok
+	}
+}
+
+// start starts sampling every interval.
+func (s *seriesSampler) start() {
+	s.begin = time.Now()
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *seriesSampler) run() {
+	defer s.wg.Done()
+
+	tick := time.NewTicker(s.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			s.sample()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// sample records the events table/collection size, and the appends and window
+// query latency since the last sample.
+func (s *seriesSampler) sample() {
+	at := time.Since(s.begin).Truncate(time.Second)
+
+	appends := atomic.SwapUint64(&s.appends, 0)
+	windows, windowAvg := s.window.take()
+	rollups, rollupAvg := s.rollup.take()
+	s.samples = append(s.samples,
+		[]string{fmt.Sprintf("Appends@%v:", at), strconv.FormatUint(appends, 10)},
+		[]string{fmt.Sprintf("WindowReads@%v:", at), strconv.FormatUint(windows, 10)},
+		[]string{fmt.Sprintf("WindowAvgLatency@%v:", at), windowAvg.String()},
+		[]string{fmt.Sprintf("Rollups@%v:", at), strconv.FormatUint(rollups, 10)},
+		[]string{fmt.Sprintf("RollupAvgLatency@%v:", at), rollupAvg.String()},
+	)
+
+	rows, size, err := s.db.EventsSize()
+	if err != nil {
+		log.Printf("events size: %v", err)
+		return
+	}
+	s.samples = append(s.samples,
+		[]string{fmt.Sprintf("EventRows@%v:", at), strconv.FormatUint(rows, 10)},
+		[]string{fmt.Sprintf("EventBytes@%v:", at), strconv.FormatUint(size, 10)},
+	)
+}
+
+// verify stops the sampler, returning the samples as name/value pairs.
+func (s *seriesSampler) verify() ([][]string, []plan.Result, error) {
+	close(s.stop)
+	s.wg.Wait()
+
+	return s.samples, nil, nil
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// ledgerShards is the number of independently locked partitions of a ledger,
+// spreading the workers over many mutexes.
+const ledgerShards = 64
+
+// ledgerEntry is the client's view of a record written during the run.
+type ledgerEntry struct {
+	// checksum of the record as last written by InsertRecord or observed by
+	// ReadRecord, valid if known is true.
+	checksum uint64
+	known    bool
+
+	// inserted is true if the record was inserted during the run.
+	inserted bool
+
+	// updated is true once UpdateRecord has changed the record since the
+	// checksum was recorded - the new content is generated by the provider so
+	// only reads returning the old checksum (stale reads) can be detected.
+	updated bool
+
+	// pending is the number of updates in progress, and version is incremented
+	// each time one completes.
+	pending int
+	version uint64
+}
+
+// ledgerShard is a partition of a ledger.
+type ledgerShard struct {
+	mu      sync.Mutex
+	records map[uint64]ledgerEntry
+}
+
+// pendingCheck is the check of a successful insert or a read of a record in the
+// ledger, left for verifier.check to run outside of the measured latency.
+type pendingCheck struct {
+	insert bool
+
+	// recordID is the ID read, before the ledger entry when the read started,
+	// into the decoded record and ok the result of the read.
+	recordID uint64
+	before   ledgerEntry
+	into     record.Record
+	ok       bool
+}
+
+// verifier wraps a dbProvider, recording the checksum of each record written
+// in a ledger and, if checkReads is true, checking records read by ReadRecord
+// against it.
+//
+// The ledger holds the records inserted or updated during the run, so it grows
+// with the number of records written rather than the size of the dataset.
+// Records are hashed and checked by check, which must be set as the
+// plan.CheckFunc so it is not included in the measured latency.
+type verifier struct {
+	dbProvider
+
+	shards     [ledgerShards]ledgerShard
+	checkReads bool
+
+	// pending holds the pendingCheck of each worker, keyed by the record passed
+	// to the call.
+	pending sync.Map
+
+	inserted                                     uint64 // atomic
+	reads, unchecked, missing, stale, mismatched uint64 // atomic
+}
+
+// newVerifier returns a verifier wrapping db.
+func newVerifier(db dbProvider, checkReads bool) *verifier {
+	v := &verifier{dbProvider: db, checkReads: checkReads}
+	for i := range v.shards {
+		v.shards[i].records = map[uint64]ledgerEntry{}
+	}
+	return v
+}
+
+// shard returns the ledger partition for recordID.
+func (v *verifier) shard(recordID uint64) *ledgerShard {
+	return &v.shards[recordID%ledgerShards]
+}
+
+// lookup returns the ledger entry for recordID.
+func (v *verifier) lookup(recordID uint64) (ledgerEntry, bool) {
+	s := v.shard(recordID)
+	s.mu.Lock()
+	e, ok := s.records[recordID]
+	s.mu.Unlock()
+	return e, ok
+}
+
+// size returns the number of records in the ledger.
+func (v *verifier) size() uint64 {
+	var n uint64
+	for i := range v.shards {
+		s := &v.shards[i]
+		s.mu.Lock()
+		n += uint64(len(s.records))
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// written returns the number of records inserted during the run.
+func (v *verifier) written() uint64 {
+	return atomic.LoadUint64(&v.inserted)
+}
+
+// InsertRecord inserts a record, leaving it to be added to the ledger by check.
+func (v *verifier) InsertRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+	n, ok := v.dbProvider.InsertRecord(data, id, rnd)
+	if ok {
+		v.pending.Store(data, &pendingCheck{insert: true})
+	}
+	return n, ok
+}
+
+// UpdateRecord updates a record, marking it as updated in the ledger.
+//
+// The update is recorded as pending before the provider writes it, so reads
+// running concurrently with the update are not checked. If the update fails
+// the record may or may not have changed, and the checksum is forgotten until
+// the record is next read.
+func (v *verifier) UpdateRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	rec := &recordingGenerator{Generator: id, onID: v.beginUpdate}
+	ok := v.dbProvider.UpdateRecord(data, rec, rnd)
+	if !rec.used {
+		return false
+	}
+
+	s := v.shard(rec.last)
+	s.mu.Lock()
+	e := s.records[rec.last]
+	e.pending--
+	e.version++
+	if ok {
+		e.updated = true
+	} else {
+		e.known = false
+	}
+	s.records[rec.last] = e
+	s.mu.Unlock()
+
+	return ok
+}
+
+// beginUpdate records an update of recordID as pending, adding it to the
+// ledger.
+func (v *verifier) beginUpdate(recordID uint64) {
+	s := v.shard(recordID)
+	s.mu.Lock()
+	e := s.records[recordID]
+	e.pending++
+	s.records[recordID] = e
+	s.mu.Unlock()
+}
+
+// ReadRecord reads a record with the wrapped provider's ReadRecord, leaving a
+// read of a record in the ledger to be checked by check.
+func (v *verifier) ReadRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	if !v.checkReads {
+		return v.dbProvider.ReadRecord(data, id, rnd)
+	}
+
+	c := &pendingCheck{}
+	var inLedger bool
+	rec := &recordingGenerator{Generator: id, onID: func(recordID uint64) {
+		c.before, inLedger = v.lookup(recordID)
+	}}
+	capture := &capturingRecord{Record: data}
+
+	ok := v.dbProvider.ReadRecord(capture, rec, rnd)
+	if rec.used && inLedger {
+		c.recordID, c.into, c.ok = rec.last, capture.empty, ok
+		v.pending.Store(data, c)
+	}
+	return ok
+}
+
+// check runs the pendingCheck left by the last call made with data.
+func (v *verifier) check(data record.Record) {
+	c, ok := v.pending.LoadAndDelete(data)
+	if !ok {
+		return
+	}
+
+	if c := c.(*pendingCheck); c.insert {
+		v.checkInsert(data)
+	} else {
+		v.checkRead(data, c)
+	}
+}
+
+// checkInsert adds the inserted record data to the ledger.
+func (v *verifier) checkInsert(data record.Record) {
+	sum := record.Checksum(data)
+	atomic.AddUint64(&v.inserted, 1)
+
+	s := v.shard(data.GetID())
+	s.mu.Lock()
+	e := s.records[data.GetID()]
+	e.checksum, e.known, e.inserted = sum, true, true
+	s.records[data.GetID()] = e
+	s.mu.Unlock()
+}
+
+// checkRead checks the record read by c against the ledger.
+//
+// A read is only checked if no update of the record was pending or completed
+// since it started. Updates acknowledged before the read started must be
+// visible - reading the content recorded before an update is a stale read.
+// Otherwise the record must match the ledger, and after an update the ledger
+// records the new content. A failed read is checked for a missing record.
+func (v *verifier) checkRead(data record.Record, c *pendingCheck) {
+	if !c.ok {
+		found, err := v.FetchRecord(data.Empty(), c.recordID)
+		if err == nil && !found {
+			log.Println(c.recordID, "missing record")
+			atomic.AddUint64(&v.missing, 1)
+		}
+		return
+	}
+
+	sum := record.Checksum(c.into)
+
+	s := v.shard(c.recordID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.records[c.recordID]
+	if c.before.pending > 0 || e.pending > 0 || e.version != c.before.version {
+		atomic.AddUint64(&v.unchecked, 1)
+		return
+	}
+
+	if c.before.known {
+		atomic.AddUint64(&v.reads, 1)
+		switch {
+		case c.before.updated && sum == c.before.checksum:
+			log.Println(c.recordID, "stale read of an updated record")
+			atomic.AddUint64(&v.stale, 1)
+			return
+		case !c.before.updated && sum != c.before.checksum:
+			log.Println(c.recordID, "record does not match the written record")
+			atomic.AddUint64(&v.mismatched, 1)
+			return
+		}
+	}
+
+	e.checksum, e.known, e.updated = sum, true, false
+	s.records[c.recordID] = e
+}
+
+// verify returns the number of reads checked against the ledger, the number of
+// reads not checked due to a concurrent update, and the number of missing,
+// stale and mismatched records read.
+func (v *verifier) verify() ([][]string, []plan.Result, error) {
+	return [][]string{
+		{"VerifiedReads:", strconv.FormatUint(atomic.LoadUint64(&v.reads), 10)},
+		{"UncheckedReads:", strconv.FormatUint(atomic.LoadUint64(&v.unchecked), 10)},
+		{"MissingRecords:", strconv.FormatUint(atomic.LoadUint64(&v.missing), 10)},
+		{"StaleReads:", strconv.FormatUint(atomic.LoadUint64(&v.stale), 10)},
+		{"Mismatches:", strconv.FormatUint(atomic.LoadUint64(&v.mismatched), 10)},
+	}, nil, nil
+}
+
+// capturingRecord wraps a Record, keeping the last Record returned by Empty so
+// the record decoded by a provider can be checked after the call.
+type capturingRecord struct {
+	record.Record
+
+	empty record.Record
+}
+
+// Empty returns a new, empty Record from the wrapped Record, keeping it.
+func (r *capturingRecord) Empty() record.Record {
+	r.empty = r.Record.Empty()
+	return r.empty
+}
+
+// recordingGenerator wraps a Generator, recording the last ID returned by
+// GetExisting and passing it to onID (if not nil) before it is returned.
+type recordingGenerator struct {
+	idgen.Generator
+	onID func(uint64)
+
+	last uint64
+	used bool
+}
+
+// GetExisting returns the ID from the wrapped Generator, recording it.
+func (g *recordingGenerator) GetExisting() uint64 {
+	g.last = g.Generator.GetExisting()
+	g.used = true
+	if g.onID != nil {
+		g.onID(g.last)
+	}
+	return g.last
+}
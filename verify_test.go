@@ -0,0 +1,245 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/domodwyer/mpjbt/idgen"
+	"github.com/domodwyer/mpjbt/record"
+)
+
+// fakeDB stores records in memory, implementing the dbProvider methods used by
+// the verifier.
+type fakeDB struct {
+	dbProvider
+
+	records map[uint64]record.Person
+
+	// stale is returned by ReadRecord instead of the stored record if set.
+	stale *record.Person
+
+	// duringRead is called by ReadRecord before it returns.
+	duringRead func()
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{records: map[uint64]record.Person{}}
+}
+
+// put stores a copy of p that is not changed when p is randomised.
+func (f *fakeDB) put(p *record.Person) {
+	c := *p
+	c.Address = append([]record.Address(nil), p.Address...)
+	c.Tags = append([]string(nil), p.Tags...)
+	f.records[p.ID] = c
+}
+
+func (f *fakeDB) InsertRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool) {
+	data.Randomise(rnd)
+	data.SetID(id.GetNew())
+	f.put(data.(*record.Person))
+	return 0, true
+}
+
+func (f *fakeDB) UpdateRecord(_ record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+	recordID := id.GetExisting()
+	p, ok := f.records[recordID]
+	if !ok {
+		return false
+	}
+	p.Balance = rnd.Float64() + 1
+	f.records[recordID] = p
+	return true
+}
+
+func (f *fakeDB) ReadRecord(data record.Record, id idgen.Generator, _ *rand.Rand) bool {
+	recordID := id.GetExisting()
+	if f.duringRead != nil {
+		f.duringRead()
+	}
+
+	p, ok := f.records[recordID]
+	if f.stale != nil {
+		p, ok = *f.stale, true
+	}
+	if !ok {
+		return false
+	}
+
+	*data.Empty().(*record.Person) = p
+	return true
+}
+
+func (f *fakeDB) FetchRecord(into record.Record, recordID uint64) (bool, error) {
+	p, ok := f.records[recordID]
+	if ok {
+		*into.(*record.Person) = p
+	}
+	return ok, nil
+}
+
+// fixedGenerator returns id from GetExisting, and increments id in GetNew.
+type fixedGenerator struct {
+	id uint64
+}
+
+func (g *fixedGenerator) GetNew() uint64 {
+	g.id++
+	return g.id
+}
+
+func (g *fixedGenerator) GetExisting() uint64 {
+	return g.id
+}
+
+// verifierRows returns the verify rows of v as a map.
+func verifierRows(t *testing.T, v *verifier) map[string]string {
+	rows, _, err := v.verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := map[string]string{}
+	for _, r := range rows {
+		out[r[0]] = r[1]
+	}
+	return out
+}
+
+func TestVerifier(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// run performs the calls, with each call followed by a check as in
+		// the plan.
+		run  func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand)
+		want map[string]string
+	}{
+		{
+			name: "read after insert",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+				v.ReadRecord(data, id, rnd)
+				v.check(data)
+			},
+			want: map[string]string{"VerifiedReads:": "1", "Mismatches:": "0", "StaleReads:": "0"},
+		},
+		{
+			name: "mismatch",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+
+				p := db.records[id.id]
+				p.Name = "corrupt"
+				db.records[id.id] = p
+
+				v.ReadRecord(data, id, rnd)
+				v.check(data)
+			},
+			want: map[string]string{"VerifiedReads:": "1", "Mismatches:": "1"},
+		},
+		{
+			name: "stale read after update",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+
+				old := db.records[id.id]
+				v.UpdateRecord(data, id, rnd)
+				v.check(data)
+
+				db.stale = &old
+				v.ReadRecord(data, id, rnd)
+				v.check(data)
+			},
+			want: map[string]string{"VerifiedReads:": "1", "StaleReads:": "1", "Mismatches:": "0"},
+		},
+		{
+			name: "read after update",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+				v.UpdateRecord(data, id, rnd)
+				v.check(data)
+
+				// The first read records the new content, the second checks it
+				for i := 0; i < 2; i++ {
+					v.ReadRecord(data, id, rnd)
+					v.check(data)
+				}
+			},
+			want: map[string]string{"VerifiedReads:": "2", "StaleReads:": "0", "Mismatches:": "0"},
+		},
+		{
+			name: "read racing an update",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+
+				// Return the content from before an update completing during
+				// the read
+				old := db.records[id.id]
+				db.duringRead = func() {
+					db.duringRead = nil
+					v.UpdateRecord(record.NewPerson(nil, nil, nil), id, rnd)
+					db.stale = &old
+				}
+				v.ReadRecord(data, id, rnd)
+				v.check(data)
+			},
+			want: map[string]string{"VerifiedReads:": "0", "UncheckedReads:": "1", "StaleReads:": "0"},
+		},
+		{
+			name: "missing record",
+			run: func(v *verifier, db *fakeDB, data record.Record, id *fixedGenerator, rnd *rand.Rand) {
+				v.InsertRecord(data, id, rnd)
+				v.check(data)
+
+				delete(db.records, id.id)
+				v.ReadRecord(data, id, rnd)
+				v.check(data)
+			},
+			want: map[string]string{"MissingRecords:": "1", "VerifiedReads:": "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			v := newVerifier(db, true)
+
+			data := record.NewPerson(nil, nil, nil)
+			tt.run(v, db, data, &fixedGenerator{}, rand.New(rand.NewSource(42)))
+
+			got := verifierRows(t, v)
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("%s got %s, want %s", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifier_ExistingRecordsNotInLedger(t *testing.T) {
+	db := newFakeDB()
+	v := newVerifier(db, true)
+	rnd := rand.New(rand.NewSource(42))
+
+	existing := record.NewPerson(nil, nil, nil)
+	existing.Randomise(rnd)
+	existing.SetID(1)
+	db.put(existing)
+
+	data := record.NewPerson(nil, nil, nil)
+	if !v.ReadRecord(data, &fixedGenerator{id: 1}, rnd) {
+		t.Fatal("read failed")
+	}
+	v.check(data)
+
+	if n := v.size(); n != 0 {
+		t.Errorf("got %d ledger entries after reading an existing record, want 0", n)
+	}
+}
@@ -3,40 +3,193 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
+	"time"
 
 	"github.com/domodwyer/mpjbt/idgen"
 	"github.com/domodwyer/mpjbt/plan"
+	"github.com/domodwyer/mpjbt/query"
 	"github.com/domodwyer/mpjbt/record"
+	"github.com/domodwyer/mpjbt/schema"
 )
 
 // dbProvider interfaces the available database methods for the underlying
 // database type.
 type dbProvider interface {
-	InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
-	UpdateRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
-	ReadRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
-	ReadRange(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
-	ReadMostRecentRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	InsertRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool)
+	UpdateRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	FetchRecord(into record.Record, recordID uint64) (bool, error)
+	CountRecords() (uint64, error)
+	ScanRecords(into record.Record, f func(record.Record, error)) error
+	ReadRange(q *query.Range) plan.CountFunc
+	ReadMostRecentRecord(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadContains(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadTagExists(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAnyTagExists(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressPath(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressPathFunc(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadBalancePath(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressLinesPath(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressLine(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressMatch(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadAddressElements(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadByPhone(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool)
+	ReadPhoneCovered(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool)
+	ReadSorted(q *query.TopN) plan.CountFunc
+	ExplainSort(q *query.TopN) (string, error)
+	ReadFields(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadFieldsObject(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ReadPageOffset(size uint64) plan.PageFunc
+	ReadPageKeyset(size uint64) plan.PageFunc
+	CountEnabled(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool)
+	AvgBalanceByAge(data record.Record, id idgen.Generator, rnd *rand.Rand) (uint64, bool)
+	TopCounters(n uint64) plan.CountFunc
+	EnabledCounts() ([]query.EnabledCount, error)
+	AgeBuckets() ([]query.AgeBucket, error)
+	CounterRanks(n uint64) ([]query.CounterRank, error)
+	PushAddress(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	GrowPadding(size record.Size, gen *record.PaddingGenerator) plan.DoFunc
+	Transfer(t query.Transfer) plan.CountFunc
+	IncrementCounter(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	SumCounters(maxID uint64) (int64, error)
+	LockWaits() (uint64, error)
+	EnqueueJob(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	ClaimJob(token uint64) (query.Job, error)
+	CompleteJob(job query.Job) error
+	QueueStats() ([][]string, error)
+	AppendEvent(ts query.TimeSeries) plan.DoFunc
+	ReadWindow(ts query.TimeSeries) plan.CountFunc
+	RollupWindow(ts query.TimeSeries) plan.CountFunc
+	EventsSize() (uint64, uint64, error)
+	InsertExpiring(ttl time.Duration) plan.CountFunc
+	PurgeExpired() (uint64, error)
+	ExpiringSize() (uint64, uint64, error)
+	InsertNotification(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	UpdateNotification(data record.Record, id idgen.Generator, rnd *rand.Rand) bool
+	Subscribe(f func(query.Notification)) (func() error, error)
 	GetMaxID() (uint64, error)
+	SampleField(field string, n int) ([]float64, error)
+
+	Setup(opts schema.Options) error
+	Teardown() error
+	Stats() ([][]string, error)
+}
+
+// monitor is implemented by providers that monitor the database in the
+// background while a workload runs.
+type monitor interface {
+	// StartMonitor starts monitoring, returning a func that stops it.
+	StartMonitor() (stop func())
 }
 
+// workloadOptions holds the workload specific configuration flags.
+type workloadOptions struct {
+	// Range query options, see query.ParseBounds for the valid bounds.
+	rangeField  string
+	rangeBounds string
+	rangeLimit  uint64
+	rangeSort   string
+
+	// Sort query options.
+	sortField    string
+	sortOrder    string
+	sortLimit    uint64
+	sortFiltered bool
+	sortDisk     bool
+
+	// Number of pages, and records per page, read by the pagination
+	// workloads.
+	pages    uint64
+	pageSize uint64
+
+	// Number of records returned by aggregate-top-counter.
+	topN uint64
+
+	// Growth options, see record.ParseSize for the valid sizes.
+	growthSize string
+	padding    *record.PaddingGenerator
+
+	// Transfer transaction options, see query.ParseIsolation for the valid
+	// isolation levels.
+	isolation string
+	txRetries int
+
+	// Number of records incremented by increment-hot.
+	hotKeys uint64
+
+	// Number of event streams, and the duration of recent events read, by the
+	// timeseries workload.
+	series int
+	window time.Duration
+
+	// Expire workload options - the lifetime of each record, how often
+	// expired records are purged in Postgres, and how often the table size is
+	// sampled.
+	ttl           time.Duration
+	purgeInterval time.Duration
+	sizeInterval  time.Duration
+
+	// Time to wait after the notify workload for outstanding notifications.
+	notifyGrace time.Duration
+
+	// Check the records read against those written during the run.
+	verifyReads bool
+
+	// Audit the stored records against those written after the run, decoding
+	// them as records from the source.
+	audit   bool
+	records record.Source
+}
+
+// verifyFunc is called after a workload completes to check the resulting data,
+// returning name/value pairs describing the outcome, and the results of any
+// measurements taken outside of the plan.
+type verifyFunc func() ([][]string, []plan.Result, error)
+
 // setWorkload configures p to run the workload identified by name, with methods
-// provided by db.
-func setWorkload(name string, p *plan.Plan, db dbProvider) error {
-	var id idgen.GeneratorSource
+// provided by db, returning a verifyFunc if the workload checks it's results
+// after the run.
+func setWorkload(name string, p *plan.Plan, db dbProvider, opts workloadOptions) (verifyFunc, error) {
+	var (
+		id     idgen.GeneratorSource
+		verify verifyFunc
+	)
 
 	// Get the current maximum ID in the database - ignore any "no data" errors
 	// when running insert workloads as the workload type doesn't require
 	// existing data.
 	max, err := db.GetMaxID()
-	if err != nil && name != "insert" && name != "insert-update" {
-		return err
+	if err != nil && name != "insert" && name != "insert-update" && name != "queue" && name != "timeseries" && name != "expire" {
+		return nil, err
+	}
+
+	// Record the records written, checking the records read against them and
+	// auditing the stored records after the run
+	var (
+		a           *auditor
+		verifyReads verifyFunc
+	)
+	if opts.verifyReads || opts.audit {
+		v := newVerifier(db, opts.verifyReads)
+		p.SetCheck(v.check)
+		if opts.verifyReads {
+			verifyReads = v.verify
+		}
+		if opts.audit {
+			before, err := db.CountRecords()
+			if err != nil {
+				return nil, err
+			}
+			a = &auditor{db: db, empty: opts.records.New(), ledger: v, before: before}
+		}
+		db = v
 	}
 
 	switch name {
 	case "insert":
 		id = &idgen.MonotonicSource{Count: max}
-		p.Add("insert", db.InsertRecord)
+		p.AddCounted("insert", "bytes", db.InsertRecord)
 
 	case "insert-update":
 		id = &idgen.PersistentSource{
@@ -44,7 +197,7 @@ func setWorkload(name string, p *plan.Plan, db dbProvider) error {
 			Source:  &idgen.MonotonicSource{Count: max},
 		}
 
-		p.Add("insert", db.InsertRecord)
+		p.AddCounted("insert", "bytes", db.InsertRecord)
 		p.Add("update", db.UpdateRecord)
 
 	case "insert-select":
@@ -53,13 +206,13 @@ func setWorkload(name string, p *plan.Plan, db dbProvider) error {
 			Source:  &idgen.MonotonicSource{Count: max},
 		}
 
-		p.Add("insert", db.InsertRecord)
+		p.AddCounted("insert", "bytes", db.InsertRecord)
 		p.Add("select", db.ReadRecord)
 
 	case "insert5-select95":
 		id = &idgen.MonotonicSource{Count: max}
 
-		p.Add("insert", db.InsertRecord)
+		p.AddCounted("insert", "bytes", db.InsertRecord)
 		for i := 0; i < 19; i++ {
 			p.Add("select", db.ReadMostRecentRecord)
 		}
@@ -95,16 +248,387 @@ func setWorkload(name string, p *plan.Plan, db dbProvider) error {
 
 		p.Add("update", db.UpdateRecord)
 
+	case "select-nested-line":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("nested-line", db.ReadAddressLine)
+
+	case "select-nested-match":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("nested-match", db.ReadAddressMatch)
+
+	case "select-nested-elements":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("nested-elements", db.ReadAddressElements)
+
+	case "select-phone-uniform", "select-phone-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "select-phone-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		p.AddCounted("select-phone", "rows", db.ReadByPhone)
+
+	case "select-phone-covered-uniform", "select-phone-covered-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "select-phone-covered-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		p.AddCounted("select-phone-covered", "rows", db.ReadPhoneCovered)
+
+	case "select-fields-uniform", "select-fields-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "select-fields-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		p.Add("select-fields", db.ReadFields)
+
+	case "select-fields-object-uniform", "select-fields-object-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "select-fields-object-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		p.Add("select-fields-object", db.ReadFieldsObject)
+
+	case "sort":
+		id = &idgen.MonotonicSource{Count: max}
+
+		q, err := parseTopN(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		p.AddCounted("sort", "rows", db.ReadSorted(q))
+
+		// Report how the server performs the sort, explained after the run so
+		// it's not included in the table statistics
+		verify = func() ([][]string, []plan.Result, error) {
+			explain, err := db.ExplainSort(q)
+			if err != nil {
+				return nil, nil, err
+			}
+			return [][]string{{"SortPlan:", explain}}, nil, nil
+		}
+
+	case "paginate-offset":
+		id = &idgen.MonotonicSource{Count: max}
+
+		if opts.pageSize == 0 {
+			return nil, fmt.Errorf("page-size must be greater than 0")
+		}
+		p.AddPaged("offset", opts.pages, db.ReadPageOffset(opts.pageSize))
+
+	case "paginate-keyset":
+		id = &idgen.MonotonicSource{Count: max}
+
+		if opts.pageSize == 0 {
+			return nil, fmt.Errorf("page-size must be greater than 0")
+		}
+		p.AddPaged("keyset", opts.pages, db.ReadPageKeyset(opts.pageSize))
+
+	case "aggregate-count-enabled":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.AddCounted("count-enabled", "rows", db.CountEnabled)
+
+	case "aggregate-avg-balance":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.AddCounted("avg-balance", "rows", db.AvgBalanceByAge)
+
+	case "aggregate-top-counter":
+		id = &idgen.MonotonicSource{Count: max}
+
+		if opts.topN == 0 {
+			return nil, fmt.Errorf("top-n must be greater than 0")
+		}
+		p.AddCounted("top-counter", "rows", db.TopCounters(opts.topN))
+
+	case "push-address-uniform", "push-address-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "push-address-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		p.Add("push-address", db.PushAddress)
+
+	case "grow-padding-uniform", "grow-padding-zipfian":
+		id = &idgen.UniformSource{Max: max}
+		if name == "grow-padding-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		size, err := record.ParseSize(opts.growthSize)
+		if err != nil {
+			return nil, fmt.Errorf("growth size: %v", err)
+		}
+		p.Add("grow-padding", db.GrowPadding(size, opts.padding))
+
+	case "transfer-uniform", "transfer-zipfian":
+		if max < 2 {
+			return nil, fmt.Errorf("transfer workloads require at least 2 records")
+		}
+
+		id = &idgen.UniformSource{Max: max}
+		if name == "transfer-zipfian" {
+			id = &idgen.ZipfianSource{Max: max}
+		}
+
+		isolation, err := query.ParseIsolation(opts.isolation)
+		if err != nil {
+			return nil, err
+		}
+		p.AddCounted("transfer", "retries", db.Transfer(query.Transfer{
+			Amount:     1,
+			Isolation:  isolation,
+			MaxRetries: opts.txRetries,
+		}))
+
 	case "read-range":
 		id = &idgen.MonotonicSource{Count: max}
 
-		p.Add("range", db.ReadRange)
+		q, err := parseRange(db, opts)
+		if err != nil {
+			return nil, err
+		}
+		p.AddCounted("range", "rows", db.ReadRange(q))
+
+	case "select-contains":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("contains", db.ReadContains)
+
+	case "select-tag-exists":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("tag-exists", db.ReadTagExists)
+
+	case "select-tag-any":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("tag-any", db.ReadAnyTagExists)
+
+	case "select-path-address":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("path-address", db.ReadAddressPath)
+
+	case "select-path-address-func":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("path-address-func", db.ReadAddressPathFunc)
+
+	case "select-path-balance":
+		id = &idgen.MonotonicSource{Count: max}
+
+		p.Add("path-balance", db.ReadBalancePath)
+
+	case "select-path-project":
+		id = &idgen.UniformSource{Max: max}
+
+		p.Add("path-project", db.ReadAddressLinesPath)
+
+	case "increment-hot":
+		if opts.hotKeys == 0 || opts.hotKeys > max {
+			return nil, fmt.Errorf("hot-keys must be between 1 and the number of records (%d)", max)
+		}
+		id = &idgen.UniformSource{Max: opts.hotKeys}
+
+		before, err := db.SumCounters(opts.hotKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		var increments uint64
+		p.Add("increment", func(data record.Record, id idgen.Generator, rnd *rand.Rand) bool {
+			ok := db.IncrementCounter(data, id, rnd)
+			if ok {
+				atomic.AddUint64(&increments, 1)
+			}
+			return ok
+		})
+
+		// Sample the lock waits while the plan runs
+		locks := newLockSampler(db)
+		p.OnStart(locks.start)
+		verify = func() ([][]string, []plan.Result, error) {
+			waits := locks.rows()
+
+			after, err := db.SumCounters(opts.hotKeys)
+			if err != nil {
+				return waits, nil, err
+			}
+			return append(query.VerifyIncrements(after-before, atomic.LoadUint64(&increments)), waits...), nil, nil
+		}
+
+	case "queue":
+		// Start the job IDs from the current time so they do not collide with
+		// those enqueued by a previous run.
+		id = queueWorkerSource{&idgen.MonotonicSource{Count: uint64(time.Now().UnixNano())}}
+
+		q := &queueOps{db: db}
+		p.Add("enqueue", db.EnqueueJob)
+		p.AddCounted("claim", "queued-us", q.claim)
+		p.Add("complete", q.complete)
+
+		verify = func() ([][]string, []plan.Result, error) {
+			rows, err := db.QueueStats()
+			return append(rows, q.rows()...), nil, err
+		}
+
+	case "timeseries":
+		id = &idgen.MonotonicSource{Count: max}
+
+		if opts.series <= 0 {
+			return nil, fmt.Errorf("series must be greater than 0")
+		}
+		if opts.sizeInterval <= 0 {
+			return nil, fmt.Errorf("size-interval must be greater than 0")
+		}
+		ts := query.NewTimeSeries(opts.series, opts.window)
+
+		s := newSeriesSampler(db, opts.sizeInterval)
+		p.Add("append", s.appended(db.AppendEvent(ts)))
+		p.AddCounted("window", "rows", s.window.timed(db.ReadWindow(ts)))
+		p.AddCounted("rollup", "rows", s.rollup.timed(db.RollupWindow(ts)))
+
+		p.OnStart(s.start)
+		verify = s.verify
+
+	case "expire":
+		id = &idgen.MonotonicSource{Count: max}
+
+		if opts.purgeInterval <= 0 || opts.sizeInterval <= 0 {
+			return nil, fmt.Errorf("purge-interval and size-interval must be greater than 0")
+		}
+		p.AddCounted("insert", "bytes", db.InsertExpiring(opts.ttl))
+
+		verify = startExpirer(db, opts.purgeInterval, opts.sizeInterval).verify
+
+	case "notify":
+		// Start from the current time so the IDs do not collide with those
+		// written by a previous run.
+		id = &idgen.PersistentSource{
+			KeepFor: 1,
+			Source:  &idgen.MonotonicSource{Count: uint64(time.Now().UnixNano())},
+		}
+
+		n, err := startNotifier(db, opts.notifyGrace)
+		if err != nil {
+			return nil, err
+		}
+		p.Add("insert", n.counted(db.InsertNotification))
+		p.Add("update", n.counted(db.UpdateNotification))
+
+		verify = n.verify
 
 	default:
-		return fmt.Errorf("unknown workload %q", name)
+		return nil, fmt.Errorf("unknown workload %q", name)
 	}
 
 	p.SetIDGenerator(id)
 
-	return nil
+	if verifyReads != nil {
+		verify = chainVerify(verify, verifyReads)
+	}
+	if a != nil {
+		verify = withAudit(verify, a)
+	}
+
+	return verify, nil
+}
+
+// chainVerify returns a verifyFunc calling first (if not nil) followed by
+// second, combining their results.
+func chainVerify(first, second verifyFunc) verifyFunc {
+	return func() ([][]string, []plan.Result, error) {
+		var (
+			rows    [][]string
+			results []plan.Result
+		)
+		if first != nil {
+			var err error
+			if rows, results, err = first(); err != nil {
+				return rows, results, err
+			}
+		}
+
+		more, extra, err := second()
+		return append(rows, more...), append(results, extra...), err
+	}
+}
+
+// withAudit returns a verifyFunc calling verify (if not nil) followed by
+// a.audit.
+func withAudit(verify verifyFunc, a *auditor) verifyFunc {
+	return func() ([][]string, []plan.Result, error) {
+		var (
+			rows    [][]string
+			results []plan.Result
+		)
+		if verify != nil {
+			var err error
+			if rows, results, err = verify(); err != nil {
+				return rows, results, err
+			}
+		}
+
+		audit, err := a.audit()
+		return append(rows, audit...), results, err
+	}
+}
+
+// parseTopN returns the sort query described by opts.
+func parseTopN(opts workloadOptions) (*query.TopN, error) {
+	if err := query.ParseSortField(opts.sortField); err != nil {
+		return nil, err
+	}
+
+	sort, err := query.ParseSort(opts.sortOrder)
+	if err != nil {
+		return nil, err
+	}
+	if sort == query.Unsorted {
+		return nil, fmt.Errorf("sort order must be asc or desc")
+	}
+
+	return &query.TopN{
+		Field:     opts.sortField,
+		Sort:      sort,
+		Limit:     opts.sortLimit,
+		Filtered:  opts.sortFiltered,
+		AllowDisk: opts.sortDisk,
+	}, nil
+}
+
+// parseRange returns the range query described by opts, sampling existing
+// records from db if required by the bounds.
+func parseRange(db dbProvider, opts workloadOptions) (*query.Range, error) {
+	if err := query.ParseField(opts.rangeField); err != nil {
+		return nil, err
+	}
+
+	sort, err := query.ParseSort(opts.rangeSort)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := query.ParseBounds(opts.rangeBounds, func(n int) ([]float64, error) {
+		return db.SampleField(opts.rangeField, n)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &query.Range{
+		Field:  opts.rangeField,
+		Bounds: bounds,
+		Limit:  opts.rangeLimit,
+		Sort:   sort,
+	}, nil
 }